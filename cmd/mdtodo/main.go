@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/darkaxi0m/mdtodo/tui"
+)
+
+func main() {
+	tui.LoadConfig()
+
+	if len(os.Args) > 1 {
+		if err := tui.RunCommand(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := tui.Run(mdtodo.NewDocument(mdtodo.DefaultFilename)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package mdtodo
 
 import (
 	"fmt"
@@ -10,11 +10,11 @@ import (
 const (
 	ApplicationName    = "mdtodo"
 	ApplicationVersion = "0.0.1"
-	BindingConfig      = "keybinding.json"
+	DefaultFilename    = "todo.md"
 )
 
 // move to shared some stage... maybe
-func getUserConfigPath(filename string) (string, error) {
+func UserConfigPath(filename string) (string, error) {
 	var configDir string
 
 	switch runtime.GOOS {
@@ -0,0 +1,276 @@
+package mdtodo
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// EventKind tells what happened to a Document
+type EventKind int
+
+const (
+	EventLoaded EventKind = iota
+	EventSaved
+	// the projects were changed without going through a Document method
+	EventChanged
+	EventProjectAdded
+	EventProjectChanged
+	EventProjectRemoved
+	EventTaskAdded
+	EventTaskChanged
+	EventTaskDone
+	EventTaskRemoved
+)
+
+func (k EventKind) String() string {
+	return [...]string{"loaded", "saved", "changed", "project added", "project changed", "project removed", "task added", "task changed", "task done", "task removed"}[k]
+}
+
+// Event is sent to the listeners of a Document after every change, Project
+// and Task are set when the event is about one
+type Event struct {
+	Kind     EventKind
+	Document *Document
+	Project  *Project
+	Task     *Task
+}
+
+// Document is a todo file and its projects. The methods keep Dirty up to
+// date and tell the listeners, code that changes Projects directly calls
+// Changed afterwards.
+type Document struct {
+	Filename string
+	Projects Projects
+	Dirty    bool
+	// Storage is the file format, nil picks it from the file name
+	Storage Storage
+
+	listeners []func(Event)
+}
+
+// NewDocument returns an empty document for the file, nothing is read yet
+func NewDocument(filename string) *Document {
+	return &Document{Filename: filename, Projects: NewProjects()}
+}
+
+// Open reads the file into a new document. A file that does not exist yet
+// gives an empty document and no error.
+func Open(filename string) (*Document, error) {
+	d := NewDocument(filename)
+	if err := d.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return d, err
+	}
+	return d, nil
+}
+
+// OnEvent adds a listener, it is called for every event of the document
+func (d *Document) OnEvent(f func(Event)) {
+	d.listeners = append(d.listeners, f)
+}
+
+// Notify marks the document dirty and tells the listeners about a change
+// made directly to the projects
+func (d *Document) Notify(kind EventKind, p *Project, t *Task) {
+	d.Dirty = true
+	d.emit(kind, p, t)
+}
+
+func (d *Document) emit(kind EventKind, p *Project, t *Task) {
+	e := Event{Kind: kind, Document: d, Project: p, Task: t}
+	for _, f := range d.listeners {
+		f(e)
+	}
+}
+
+// Load (re)reads the file, the projects are replaced
+func (d *Document) Load() error {
+	ps, err := d.storage().Load(d.Filename)
+	d.Projects = ps
+	d.Dirty = false
+	if err != nil {
+		return err
+	}
+	d.emit(EventLoaded, nil, nil)
+	return nil
+}
+
+// Save writes the file
+func (d *Document) Save() error {
+	if err := d.storage().Save(d.Filename, d.Projects); err != nil {
+		return err
+	}
+	d.Dirty = false
+	d.emit(EventSaved, nil, nil)
+	return nil
+}
+
+func (d *Document) storage() Storage {
+	if d.Storage != nil {
+		return d.Storage
+	}
+	return StorageFor(d.Filename)
+}
+
+// Changed marks the document dirty after the projects were changed directly,
+// without saying what changed
+func (d *Document) Changed() {
+	d.Notify(EventChanged, nil, nil)
+}
+
+// Project returns the project with that name, nil if there is none
+func (d *Document) Project(name string) *Project {
+	for _, p := range d.Projects.Items {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// AddProject adds a project at the end, or returns the one with that name
+func (d *Document) AddProject(name string) *Project {
+	if p := d.Project(name); p != nil {
+		return p
+	}
+	p := NewProject(name)
+	d.Projects.Items = append(d.Projects.Items, p)
+	d.Notify(EventProjectAdded, p, nil)
+	return p
+}
+
+func (d *Document) RemoveProject(p *Project) {
+	d.Projects.Remove(p)
+	d.Notify(EventProjectRemoved, p, nil)
+}
+
+// EditProject renames the project or changes its notes, nil ones stay
+func (d *Document) EditProject(p *Project, name, notes *string) {
+	if name != nil {
+		p.Name = strings.TrimSpace(*name)
+	}
+	if notes != nil {
+		p.Notes = *notes
+	}
+	d.Notify(EventProjectChanged, p, nil)
+}
+
+// AddTask adds an open task at the end of the project, a leading emoji
+// becomes its tag like when the file is read. The other fields of the edit
+// are set before the listeners hear of the task, its Project is ignored.
+func (d *Document) AddTask(p *Project, name string, e TaskEdit) *Task {
+	tag, name := ExtractEmoji(strings.TrimSpace(name))
+	t := &Task{Status: StatusOpen, Name: name, Tag: tag}
+	p.Tasks.Items = append(p.Tasks.Items, t)
+	e.Name, e.Project = nil, nil
+	applyEdit(p, t, e)
+	d.Notify(EventTaskAdded, p, t)
+	return t
+}
+
+// SetStatus changes the state of a task, see Task.SetStatus. Completing a
+// task sends EventTaskDone, any other change EventTaskChanged.
+func (d *Document) SetStatus(p *Project, t *Task, s Status) bool {
+	if t.SetStatus(s) {
+		d.Notify(EventTaskDone, p, t)
+		return true
+	}
+	d.Notify(EventTaskChanged, p, t)
+	return false
+}
+
+func (d *Document) RemoveTask(p *Project, t *Task) {
+	p.Tasks.Remove(t)
+	d.Notify(EventTaskRemoved, p, t)
+}
+
+// MoveTask moves a task to the end of another project
+func (d *Document) MoveTask(t *Task, from, to *Project) {
+	if from == to {
+		return
+	}
+	moveTask(t, from, to)
+	d.Notify(EventTaskChanged, to, t)
+}
+
+func moveTask(t *Task, from, to *Project) {
+	from.Tasks.Remove(t)
+	to.Tasks.Items = append(to.Tasks.Items, t)
+}
+
+// ReorderTask moves a task to another place in its project, index is
+// clamped to the tasks there
+func (d *Document) ReorderTask(p *Project, t *Task, index int) {
+	reorderTask(p, t, index)
+	d.Notify(EventTaskChanged, p, t)
+}
+
+func reorderTask(p *Project, t *Task, index int) {
+	p.Tasks.Remove(t)
+	index = max(0, min(index, len(p.Tasks.Items)))
+	p.Tasks.Items = append(p.Tasks.Items[:index], append([]*Task{t}, p.Tasks.Items[index:]...)...)
+}
+
+// TaskEdit changes several fields of a task at once, nil ones stay as they
+// are
+type TaskEdit struct {
+	Name    *string
+	Tag     *string
+	Notes   *string
+	Status  *Status
+	Project *Project // moves the task to the end of that project
+	Index   *int     // moves it inside the project it ends up in
+}
+
+// EditTask applies the edit and then tells the listeners once, with
+// EventTaskDone when it completed the task, so they see all of it. It
+// returns the project the task is in afterwards.
+func (d *Document) EditTask(p *Project, t *Task, e TaskEdit) *Project {
+	p, completed := applyEdit(p, t, e)
+	if completed {
+		d.Notify(EventTaskDone, p, t)
+	} else {
+		d.Notify(EventTaskChanged, p, t)
+	}
+	return p
+}
+
+// applyEdit changes the task without telling anyone, it returns where the
+// task is now and if it got completed
+func applyEdit(p *Project, t *Task, e TaskEdit) (*Project, bool) {
+	if e.Name != nil {
+		t.Name = strings.TrimSpace(*e.Name)
+	}
+	if e.Tag != nil {
+		t.Tag = *e.Tag
+	}
+	if e.Notes != nil {
+		t.Notes = *e.Notes
+	}
+	completed := false
+	if e.Status != nil && *e.Status != t.Status {
+		completed = t.SetStatus(*e.Status)
+	}
+	if e.Project != nil && e.Project != p {
+		moveTask(t, p, e.Project)
+		p = e.Project
+	}
+	if e.Index != nil {
+		reorderTask(p, t, *e.Index)
+	}
+	return p, completed
+}
+
+// Find returns the task and its project for the first task whose name
+// contains s, ignoring case
+func (d *Document) Find(s string) (*Project, *Task) {
+	s = strings.ToLower(s)
+	for _, p := range d.Projects.Items {
+		for _, t := range p.Tasks.Items {
+			if strings.Contains(strings.ToLower(t.Name), s) {
+				return p, t
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package mdtodo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEditTaskNotifiesOnce(t *testing.T) {
+	d := NewDocument("todo.md")
+	d.Projects = readProjects(t, "# Todo\n\n## Main\n- [ ] first\n- [ ] second\n\n## Other\n- [ ] third\n")
+	main, other := d.Projects.Items[0], d.Projects.Items[1]
+	var events []Event
+	d.OnEvent(func(e Event) { events = append(events, e) })
+
+	name, status, index := "renamed", StatusDone, 0
+	p := d.EditTask(main, main.Tasks.Items[1], TaskEdit{Name: &name, Status: &status, Project: other, Index: &index})
+	if p != other || !strings.HasPrefix(other.Tasks.Items[0].Name, "renamed ") || !other.Tasks.Items[0].Status.Closed() {
+		t.Errorf("edit ended in %s with %v", p.Name, other.Tasks.Items)
+	}
+	if len(events) != 1 || events[0].Kind != EventTaskDone || events[0].Project != other {
+		t.Errorf("got events %v, want one task done in Other", events)
+	}
+
+	events = nil
+	notes := "a note"
+	task := d.AddTask(main, "🚀 write", TaskEdit{Notes: &notes, Project: other})
+	if task.Tag != "🚀" || task.Notes != "a note" || main.Tasks.Items[len(main.Tasks.Items)-1] != task {
+		t.Errorf("added %+v", task)
+	}
+	if len(events) != 1 || events[0].Kind != EventTaskAdded || events[0].Task != task {
+		t.Errorf("got events %v, want one task added", events)
+	}
+}
+
+func TestJSONTaskEdit(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] first\n")
+	bad, missing := "sideways", "nope"
+	if _, err := (JSONTaskEdit{Status: &bad}).TaskEdit(ps); err == nil {
+		t.Error("an unknown status was taken")
+	}
+	var nf *NotFoundError
+	if _, err := (JSONTaskEdit{Project: &missing}).TaskEdit(ps); !errors.As(err, &nf) || nf.ID != missing {
+		t.Errorf("an unknown project gave %v", err)
+	}
+	id := ProjectID(ps.Items[0])
+	if e, err := (JSONTaskEdit{Project: &id}).TaskEdit(ps); err != nil || e.Project != ps.Items[0] {
+		t.Errorf("project %s gave %v, %v", id, e.Project, err)
+	}
+}
@@ -0,0 +1,41 @@
+package mdtodo
+
+import (
+	"regexp"
+	"time"
+)
+
+const DoneMark = "✅"
+
+// DoneRegex matches the completion date, obsidian tasks style too, `✅ 2026-10-17`
+var DoneRegex = regexp.MustCompile(`\s*` + DoneMark + `\s*(\d{4}-\d{2}-\d{2})`)
+
+// Completed returns the completion date found in the task name
+func (t Task) Completed() (time.Time, bool) {
+	m := DoneRegex.FindStringSubmatch(t.Name)
+	if m == nil {
+		return time.Time{}, false
+	}
+	d, err := time.ParseInLocation(DateLayout, m[1], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// SetStatus changes the state and stamps the completion date on tasks that
+// get done, or takes it off again when they are reopened. It reports if the
+// task was just completed.
+func (t *Task) SetStatus(s Status) bool {
+	wasDone := t.Status.IsDone()
+	t.Status = s
+	switch {
+	case s.IsDone() && !wasDone:
+		t.Name = DoneRegex.ReplaceAllString(t.Name, "")
+		t.Name += " " + DoneMark + " " + time.Now().Format(DateLayout)
+		return true
+	case !s.IsDone():
+		t.Name = DoneRegex.ReplaceAllString(t.Name, "")
+	}
+	return false
+}
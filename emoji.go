@@ -1,4 +1,4 @@
-package main
+package mdtodo
 
 import (
 	"strings"
@@ -30,7 +30,7 @@ func isEmojiStart(s string) bool {
 	}
 }
 
-func extractEmoji(s string) (string, string) {
+func ExtractEmoji(s string) (string, string) {
 	if s == "" || !isEmojiStart(s) {
 		return "", s // No emoji found
 	}
@@ -0,0 +1,376 @@
+package mdtodo
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitRoot walks up from the folder of filename to the one holding .git,
+// like DetectProjectName
+func GitRoot(filename string) (string, bool) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return "", false
+	}
+	dir := filepath.Dir(absPath)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// git runs git in the repository of filename, with the path of the file
+// relative to its root
+func git(filename string, args ...string) ([]byte, error) {
+	root, ok := GitRoot(filename)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in a git repository", filename)
+	}
+	cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return out, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// gitPath is filename relative to the root of its repository, with slashes
+func gitPath(filename string) (string, error) {
+	root, ok := GitRoot(filename)
+	if !ok {
+		return "", fmt.Errorf("%s is not in a git repository", filename)
+	}
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+//---------Commit messages-----------------------------
+
+// ChangeSummary sums up the events of a document for a commit message
+type ChangeSummary struct {
+	done            []string
+	added           map[*Task]bool
+	changed         map[*Task]bool
+	removed         map[*Task]bool
+	projectsAdded   []string
+	projectsRemoved []string
+	projectsChanged []string
+	// moves, reorders and edits made without saying what changed
+	other bool
+}
+
+func (s *ChangeSummary) Add(e Event) {
+	if s.added == nil {
+		s.added, s.changed, s.removed = map[*Task]bool{}, map[*Task]bool{}, map[*Task]bool{}
+	}
+	switch e.Kind {
+	case EventTaskAdded:
+		s.added[e.Task] = true
+	case EventTaskDone:
+		s.done = append(s.done, strings.TrimSpace(DoneRegex.ReplaceAllString(e.Task.Name, "")))
+	case EventTaskChanged:
+		if !s.added[e.Task] {
+			s.changed[e.Task] = true
+		}
+	case EventTaskRemoved:
+		if s.added[e.Task] {
+			delete(s.added, e.Task)
+		} else {
+			s.removed[e.Task] = true
+		}
+		delete(s.changed, e.Task)
+	case EventProjectAdded:
+		s.projectsAdded = append(s.projectsAdded, e.Project.Name)
+	case EventProjectRemoved:
+		s.projectsRemoved = append(s.projectsRemoved, e.Project.Name)
+	case EventProjectChanged:
+		if !slices.Contains(s.projectsChanged, e.Project.Name) {
+			s.projectsChanged = append(s.projectsChanged, e.Project.Name)
+		}
+	case EventChanged:
+		s.other = true
+	}
+}
+
+func tasks(n int) string {
+	if n == 1 {
+		return "1 task"
+	}
+	return fmt.Sprintf("%d tasks", n)
+}
+
+func quoted(names []string) string {
+	return "'" + strings.Join(names, "', '") + "'"
+}
+
+// Message is eg "mdtodo: done 'reorder tasks'; added 2 tasks", a few names
+// are spelled out and more are counted
+func (s *ChangeSummary) Message() string {
+	var parts []string
+	switch {
+	case len(s.done) > 0 && len(s.done) <= 3:
+		parts = append(parts, "done "+quoted(s.done))
+	case len(s.done) > 3:
+		parts = append(parts, "done "+tasks(len(s.done)))
+	}
+	if len(s.added) > 0 {
+		parts = append(parts, "added "+tasks(len(s.added)))
+	}
+	if len(s.changed) > 0 {
+		parts = append(parts, "changed "+tasks(len(s.changed)))
+	}
+	if len(s.removed) > 0 {
+		parts = append(parts, "removed "+tasks(len(s.removed)))
+	}
+	if len(s.projectsAdded) > 0 {
+		parts = append(parts, "added project "+quoted(s.projectsAdded))
+	}
+	if len(s.projectsRemoved) > 0 {
+		parts = append(parts, "removed project "+quoted(s.projectsRemoved))
+	}
+	if len(s.projectsChanged) > 0 {
+		parts = append(parts, "changed project "+quoted(s.projectsChanged))
+	}
+	switch {
+	case s.other && len(parts) == 0:
+		parts = append(parts, "edited the list")
+	case s.other:
+		parts = append(parts, "other edits")
+	case len(parts) == 0:
+		parts = append(parts, "update")
+	}
+	return ApplicationName + ": " + strings.Join(parts, "; ")
+}
+
+//---------Auto commit-----------------------------
+
+// GitCommitter commits the file of a document to its git repository after
+// it is saved. Saves closer together than Delay make one commit, and later
+// commits of the session amend the first one as long as nobody committed
+// meanwhile, so a session shows up as one commit with all its changes.
+type GitCommitter struct {
+	Delay time.Duration
+	// OnError hears of the commits after Delay that fail, it runs on their
+	// own goroutine. Nil logs them.
+	OnError func(error)
+
+	// mu guards what OnEvent notes, git runs outside of it
+	mu      sync.Mutex
+	file    string
+	pending bool
+	timer   *time.Timer
+	events  []Event
+
+	// flushing makes one commit at a time and guards the commit of this
+	// session, its changes and what HEAD was before it
+	flushing sync.Mutex
+	session  []Event
+	commit   string
+	previous string
+}
+
+func NewGitCommitter(delay time.Duration) *GitCommitter {
+	return &GitCommitter{Delay: delay}
+}
+
+// OnEvent is the Document listener, it notes the changes and commits a
+// while after the save
+func (c *GitCommitter) OnEvent(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch e.Kind {
+	case EventLoaded:
+		return
+	case EventSaved:
+		if _, ok := GitRoot(e.Document.Filename); !ok || e.Document.Filename == "" {
+			return
+		}
+		c.file = e.Document.Filename
+		c.pending = true
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		c.timer = time.AfterFunc(c.Delay, func() {
+			if err := c.Flush(); err != nil && c.OnError != nil {
+				c.OnError(err)
+			} else if err != nil {
+				log.Printf("Error %v\n", err)
+			}
+		})
+	default:
+		// nothing commits them, they would pile up
+		if _, ok := GitRoot(e.Document.Filename); !ok || e.Document.Filename == "" {
+			return
+		}
+		c.events = append(c.events, e)
+	}
+}
+
+// Flush commits a pending save right away, eg before quitting. The changes
+// made while git runs wait for the next save.
+func (c *GitCommitter) Flush() error {
+	c.flushing.Lock()
+	defer c.flushing.Unlock()
+
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	file, pending, events := c.file, c.pending, c.events
+	c.pending, c.events = false, nil
+	c.mu.Unlock()
+	if !pending {
+		return nil
+	}
+	if err := c.commitFile(file, events); err != nil {
+		return fmt.Errorf("committing %s: %v", file, err)
+	}
+	return nil
+}
+
+// commitFile commits the file with a message made of the events, or amends
+// the commit of the session
+func (c *GitCommitter) commitFile(file string, events []Event) error {
+	path, err := gitPath(file)
+	if err != nil {
+		return err
+	}
+	head := ""
+	if out, err := git(file, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		head = strings.TrimSpace(string(out))
+	}
+	// a pushed commit stays, the session goes on in a new one
+	amend := head != "" && head == c.commit && !pushed(file)
+
+	if _, err := git(file, "add", "--", path); err != nil {
+		return err
+	}
+	base := head
+	if amend {
+		events = append(append([]Event{}, c.session...), events...)
+		base = c.previous
+	}
+	// back to what the commit replaces means no commit
+	if base != "" {
+		if _, err := git(file, "diff", "--cached", "--quiet", base, "--", path); err == nil {
+			if amend {
+				_, err = git(file, "reset", "--soft", "--quiet", base)
+				c.commit, c.session = "", nil
+				return err
+			}
+			return nil
+		}
+	}
+
+	var summary ChangeSummary
+	for _, e := range events {
+		summary.Add(e)
+	}
+	args := []string{"commit", "--quiet", "-m", summary.Message()}
+	if amend {
+		args = append(args, "--amend")
+	}
+	if _, err := git(file, append(args, "--", path)...); err != nil {
+		return err
+	}
+	if !amend {
+		c.previous = head
+	}
+	out, err := git(file, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	c.commit = strings.TrimSpace(string(out))
+	c.session = events
+	return nil
+}
+
+// pushed reports if HEAD is on the upstream branch already, amending it
+// would rewrite published history
+func pushed(file string) bool {
+	_, err := git(file, "merge-base", "--is-ancestor", "HEAD", "@{u}")
+	return err == nil
+}
+
+//---------History-----------------------------
+
+// GitVersion is a commit that changed a todo file
+type GitVersion struct {
+	Hash    string
+	Date    time.Time
+	Subject string
+}
+
+// GitLog lists the commits of the file, newest first
+func GitLog(filename string, limit int) ([]GitVersion, error) {
+	path, err := gitPath(filename)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(filename, "log", fmt.Sprintf("--max-count=%d", limit), "--format=%H%x09%aI%x09%s", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var versions []GitVersion
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[1])
+		versions = append(versions, GitVersion{Hash: fields[0], Date: date, Subject: fields[2]})
+	}
+	return versions, nil
+}
+
+// GitDiff is the diff from a version of the file to the file on disk
+func GitDiff(filename, hash string) (string, error) {
+	path, err := gitPath(filename)
+	if err != nil {
+		return "", err
+	}
+	out, err := git(filename, "diff", "--no-color", "--no-ext-diff", hash, "--", path)
+	return string(out), err
+}
+
+// LoadGitVersion reads the file as it was in a commit
+func LoadGitVersion(filename, hash string) (Projects, error) {
+	path, err := gitPath(filename)
+	if err != nil {
+		return NewProjects(), err
+	}
+	out, err := git(filename, "show", hash+":"+path)
+	if err != nil {
+		return NewProjects(), err
+	}
+	if _, ok := StorageFor(filename).(TodoTxt); ok {
+		return ReadTodoTxt(bytes.NewReader(out))
+	}
+	return ReadFrom(bytes.NewReader(out))
+}
@@ -0,0 +1,103 @@
+package mdtodo
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangeSummary(t *testing.T) {
+	task := &Task{Name: "write ✅ 2026-01-02"}
+	home, work := &Project{Name: "Home"}, &Project{Name: "Work"}
+	tests := []struct {
+		events []Event
+		want   string
+	}{
+		{nil, "update"},
+		{[]Event{{Kind: EventChanged}}, "edited the list"},
+		{[]Event{{Kind: EventTaskDone, Task: task}, {Kind: EventChanged}}, "done 'write'; other edits"},
+		{[]Event{{Kind: EventProjectChanged, Project: home}, {Kind: EventProjectChanged, Project: work}, {Kind: EventProjectChanged, Project: home}}, "changed project 'Home', 'Work'"},
+		{[]Event{{Kind: EventTaskAdded, Task: task}, {Kind: EventTaskChanged, Task: task}}, "added 1 task"},
+		{[]Event{{Kind: EventTaskAdded, Task: task}, {Kind: EventTaskRemoved, Task: task}}, "update"},
+	}
+	for _, tt := range tests {
+		var s ChangeSummary
+		for _, e := range tt.events {
+			s.Add(e)
+		}
+		if got := s.Message(); got != ApplicationName+": "+tt.want {
+			t.Errorf("%v: got %q, want %q", tt.events, got, tt.want)
+		}
+	}
+}
+
+// gitRun runs git in dir and fails the test when it does
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestGitCommitterKeepsPushedCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no git")
+	}
+	for _, k := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+k+"_NAME", "test")
+		t.Setenv("GIT_"+k+"_EMAIL", "test@example.com")
+	}
+	dir := t.TempDir()
+	remote, repo := filepath.Join(dir, "remote.git"), filepath.Join(dir, "repo")
+	gitRun(t, dir, "init", "--quiet", "--bare", remote)
+	gitRun(t, dir, "init", "--quiet", repo)
+
+	d := NewDocument(filepath.Join(repo, "todo.md"))
+	d.Projects = readProjects(t, "# Todo\n\n## Main\n- [ ] first\n")
+	c := NewGitCommitter(0)
+	d.OnEvent(c.OnEvent)
+	save := func() {
+		t.Helper()
+		if err := d.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	save()
+	d.AddTask(d.Projects.Items[0], "second", TaskEdit{})
+	save()
+	if n := gitRun(t, repo, "rev-list", "--count", "HEAD"); n != "1" {
+		t.Fatalf("the session made %s commits before the push, want 1", n)
+	}
+
+	gitRun(t, repo, "remote", "add", "origin", remote)
+	gitRun(t, repo, "push", "--quiet", "--set-upstream", "origin", "HEAD")
+	pushed := gitRun(t, repo, "rev-parse", "HEAD")
+	d.AddTask(d.Projects.Items[0], "third", TaskEdit{})
+	save()
+	if n := gitRun(t, repo, "rev-list", "--count", "HEAD"); n != "2" {
+		t.Errorf("the session made %s commits after the push, want 2", n)
+	}
+	if parent := gitRun(t, repo, "rev-parse", "HEAD~1"); parent != pushed {
+		t.Errorf("the pushed commit %s was rewritten, HEAD~1 is %s", pushed, parent)
+	}
+}
+
+func TestGitCommitterOutsideARepository(t *testing.T) {
+	d := NewDocument(filepath.Join(t.TempDir(), "todo.md"))
+	d.Projects = readProjects(t, "# Todo\n\n## Main\n")
+	c := NewGitCommitter(0)
+	d.OnEvent(c.OnEvent)
+	for range 3 {
+		d.AddTask(d.Projects.Items[0], "task", TaskEdit{})
+	}
+	if len(c.events) != 0 {
+		t.Errorf("%d events kept for a file outside git", len(c.events))
+	}
+}
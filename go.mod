@@ -2,15 +2,16 @@ module github.com/darkaxi0m/mdtodo
 
 go 1.24
 
-require github.com/jesseduffield/gocui v0.3.1-0.20240418080333-8cd33929c513
+require (
+	github.com/jesseduffield/gocui v0.3.1-0.20240418080333-8cd33929c513
+	github.com/mattn/go-runewidth v0.0.15
+)
 
 require (
 	github.com/gdamore/encoding v1.0.0 // indirect
 	github.com/gdamore/tcell/v2 v2.7.4 // indirect
 	github.com/go-errors/errors v1.0.2 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
-	github.com/mattn/go-runewidth v0.0.15 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
 	github.com/rivo/uniseg v0.4.3 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/term v0.17.0 // indirect
@@ -0,0 +1,113 @@
+package mdtodo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// hooks are executables in the hooks folder of the config directory, named
+// after the events they run on
+var hookNames = map[EventKind]string{
+	EventTaskAdded:      "on_add",
+	EventProjectAdded:   "on_add",
+	EventTaskDone:       "on_done",
+	EventTaskChanged:    "on_change",
+	EventProjectChanged: "on_change",
+	EventTaskRemoved:    "on_delete",
+	EventProjectRemoved: "on_delete",
+	EventSaved:          "on_save",
+	EventLoaded:         "on_load",
+}
+
+// HookPayload is what a hook reads on stdin
+type HookPayload struct {
+	Event   string       `json:"event"`
+	File    string       `json:"file"`
+	Project *HookProject `json:"project,omitempty"`
+	Task    *JSONTask    `json:"task,omitempty"`
+}
+
+type HookProject struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// HookPath is the executable run for the event, "" when there is none
+func HookPath(kind EventKind) string {
+	name, ok := hookNames[kind]
+	if !ok {
+		return ""
+	}
+	path, err := UserConfigPath(filepath.Join("hooks", name))
+	if err != nil {
+		return ""
+	}
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() || fi.Mode()&0111 == 0 {
+		return ""
+	}
+	return path
+}
+
+// RunHooks is a Document listener that runs the hook of the event in the
+// background, like SendHeartbeat runs wakatime. The hook gets the task and
+// project as JSON on stdin and as MDTODO_ variables. Hooks that fail are
+// logged, see Hooks.
+func RunHooks(e Event) {
+	runHook(e, nil)
+}
+
+// Hooks is RunHooks telling onError of the hooks that fail, it runs on
+// their own goroutine. Nil logs them.
+func Hooks(onError func(error)) func(Event) {
+	return func(e Event) {
+		runHook(e, onError)
+	}
+}
+
+func runHook(e Event, onError func(error)) {
+	path := HookPath(e.Kind)
+	if path == "" {
+		return
+	}
+
+	file, _ := filepath.Abs(e.Document.Filename)
+	payload := HookPayload{Event: e.Kind.String(), File: file}
+	env := append(os.Environ(), "MDTODO_EVENT="+payload.Event, "MDTODO_FILE="+file)
+	if p := e.Project; p != nil {
+		payload.Project = &HookProject{ID: ProjectID(p), Name: p.Name, Notes: p.Notes}
+		env = append(env, "MDTODO_PROJECT="+p.Name)
+		if p.File != "" {
+			payload.File = p.File
+			env = append(env, "MDTODO_FILE="+p.File)
+		}
+	}
+	if t := e.Task; t != nil {
+		e.Document.Projects.AssignIDs()
+		jt := newJSONTask(t)
+		payload.Task = &jt
+		env = append(env, "MDTODO_TASK="+t.Name, "MDTODO_TASK_ID="+jt.ID, "MDTODO_STATUS="+jt.Status)
+	}
+	// the payload is taken now, the document goes on changing
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		cmd := exec.Command(path)
+		cmd.Env = env
+		cmd.Dir = filepath.Dir(payload.File)
+		cmd.Stdin = bytes.NewReader(input)
+		if err := cmd.Run(); err != nil && onError != nil {
+			onError(fmt.Errorf("hook %s: %v", filepath.Base(path), err))
+		} else if err != nil {
+			log.Printf("Error running hook %s: %v\n", path, err)
+		}
+	}()
+}
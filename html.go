@@ -0,0 +1,248 @@
+package mdtodo
+
+import (
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HTMLOptions changes what WriteHTML shows
+type HTMLOptions struct {
+	Title    string
+	HideDone bool
+	// Now decides which due dates are overdue, zero is the current time
+	Now time.Time
+}
+
+type htmlTask struct {
+	Name      string
+	Tag       string
+	Tags      []string
+	Class     string
+	Status    string
+	Due       string
+	DueClass  string
+	Completed string
+	Notes     template.HTML
+}
+
+type htmlProject struct {
+	Name    string
+	Done    int
+	Total   int
+	Percent int
+	Notes   template.HTML
+	Tasks   []htmlTask
+}
+
+type htmlPage struct {
+	Title     string
+	Generated string
+	Done      int
+	Total     int
+	Percent   int
+	Projects  []htmlProject
+}
+
+var htmlBullet = regexp.MustCompile(`^\s*[-*+] `)
+
+// htmlInline renders the inline markdown of a line, see ParseInline. Links
+// only to web and mail addresses, others stay text.
+func htmlInline(line string) string {
+	var sb strings.Builder
+	for _, in := range ParseInline(line) {
+		text := html.EscapeString(in.Text)
+		switch in.Kind {
+		case InlineBold:
+			sb.WriteString("<strong>" + text + "</strong>")
+		case InlineItalic:
+			sb.WriteString("<em>" + text + "</em>")
+		case InlineCode:
+			sb.WriteString("<code>" + text + "</code>")
+		case InlineLink:
+			url := strings.ToLower(in.Link)
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "mailto:") {
+				sb.WriteString(text)
+				break
+			}
+			sb.WriteString(`<a href="` + html.EscapeString(in.Link) + `">` + text + `</a>`)
+		default:
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
+
+// notesHTML renders notes like the details pane does, headings, quotes,
+// bullets and the common inline markdown
+func notesHTML(notes string) template.HTML {
+	if notes == "" {
+		return ""
+	}
+	var sb strings.Builder
+	inList := false
+	for _, line := range strings.Split(notes, "\n") {
+		bullet := htmlBullet.MatchString(line)
+		if inList && !bullet {
+			sb.WriteString("</ul>")
+			inList = false
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case bullet:
+			if !inList {
+				sb.WriteString("<ul>")
+				inList = true
+			}
+			sb.WriteString("<li>" + htmlInline(htmlBullet.ReplaceAllString(line, "")) + "</li>")
+		case strings.HasPrefix(line, "#"):
+			sb.WriteString("<p><strong>" + htmlInline(strings.TrimSpace(strings.TrimLeft(line, "#"))) + "</strong></p>")
+		case strings.HasPrefix(line, ">"):
+			sb.WriteString("<blockquote>" + htmlInline(strings.TrimSpace(strings.TrimPrefix(line, ">"))) + "</blockquote>")
+		case trimmed != "":
+			sb.WriteString("<p>" + htmlInline(trimmed) + "</p>")
+		}
+	}
+	if inList {
+		sb.WriteString("</ul>")
+	}
+	return template.HTML(sb.String())
+}
+
+func newHTMLTask(t *Task, now time.Time) htmlTask {
+	name := DoneRegex.ReplaceAllString(t.Name, "")
+	name = dueRegex.ReplaceAllString(name, "")
+	var tags []string
+	for _, m := range hashTagRegex.FindAllStringSubmatch(name, -1) {
+		tags = append(tags, m[2])
+	}
+	name = hashTagRegex.ReplaceAllString(name, "$1")
+
+	ht := htmlTask{
+		Name:   strings.Join(strings.Fields(name), " "),
+		Tag:    t.Tag,
+		Tags:   tags,
+		Class:  t.Status.Name(),
+		Status: "☐",
+		Notes:  notesHTML(t.Notes),
+	}
+	switch {
+	case t.Status.IsDone():
+		ht.Status = "☑"
+	case t.Status == StatusCancelled:
+		ht.Status = "⊘"
+	case t.Status == StatusDoing:
+		ht.Status = "◐"
+	case t.Status == StatusDeferred:
+		ht.Status = "↷"
+	}
+	if d, ok := t.Due(); ok {
+		ht.Due = d.Format(DateLayout)
+		switch {
+		case t.Done():
+		case t.DueBy(now.AddDate(0, 0, -1)):
+			ht.DueClass = "overdue"
+		case t.DueBy(now):
+			ht.DueClass = "today"
+		}
+	}
+	if d, ok := t.Completed(); ok && t.Status.Closed() {
+		ht.Completed = d.Format(DateLayout)
+	}
+	return ht
+}
+
+// WriteHTML writes a self contained page, styles included and nothing
+// loaded from the network
+func WriteHTML(w io.Writer, ps Projects, opts HTMLOptions) error {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.Title == "" {
+		opts.Title = "Todo"
+	}
+	page := htmlPage{Title: opts.Title, Generated: opts.Now.Format("2006-01-02 15:04")}
+	for _, p := range ps.Items {
+		done, total := p.Progress()
+		page.Done += done
+		page.Total += total
+		hp := htmlProject{Name: p.Name, Done: done, Total: total, Percent: percent(done, total), Notes: notesHTML(p.Notes)}
+		for _, t := range p.Tasks.Items {
+			if opts.HideDone && t.Done() {
+				continue
+			}
+			hp.Tasks = append(hp.Tasks, newHTMLTask(t, opts.Now))
+		}
+		page.Projects = append(page.Projects, hp)
+	}
+	page.Percent = percent(page.Done, page.Total)
+	return htmlTemplate.Execute(w, page)
+}
+
+func init() {
+	RegisterRenderer("html", RendererFunc(func(w io.Writer, ps Projects) error {
+		return WriteHTML(w, ps, HTMLOptions{})
+	}))
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #222; background: #fafafa; }
+header { display: flex; align-items: baseline; gap: 1rem; flex-wrap: wrap; }
+h1 { margin: 0; }
+.muted { color: #777; font-size: .9rem; }
+.bar { background: #e4e4e4; border-radius: 4px; height: .6rem; overflow: hidden; flex: 1; min-width: 8rem; }
+.bar span { display: block; height: 100%; background: #3a9a4a; }
+.progress { display: flex; align-items: center; gap: .75rem; margin: .5rem 0 1rem; }
+section { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: .5rem 1rem 1rem; margin: 1rem 0; box-shadow: 0 1px 2px rgba(0,0,0,.05); }
+h2 { margin: .5rem 0 0; font-size: 1.2rem; }
+ul.tasks { list-style: none; padding: 0; margin: 0; }
+ul.tasks > li { padding: .35rem 0; border-top: 1px solid #eee; }
+.state { display: inline-block; width: 1.2rem; }
+.done .name, .cancelled .name { color: #888; text-decoration: line-through; }
+.doing .state { color: #b8860b; }
+.chip { display: inline-block; font-size: .75rem; padding: 0 .45rem; border-radius: 999px; background: #e8eefc; color: #2a4a9a; margin-left: .25rem; }
+.due { display: inline-block; font-size: .75rem; padding: 0 .45rem; border-radius: 4px; background: #eee; margin-left: .25rem; }
+.due.today { background: #fff1c2; color: #7a5a00; }
+.due.overdue { background: #fbd5d5; color: #9a1a1a; }
+.notes { margin: .25rem 0 0 1.2rem; color: #444; font-size: .9rem; }
+.notes p, .notes ul, .notes blockquote { margin: .2rem 0; }
+.notes blockquote { border-left: 3px solid #ccc; padding-left: .5rem; color: #666; }
+code { background: #f0f0f0; padding: 0 .2rem; border-radius: 3px; }
+@media print {
+  body { background: #fff; margin: 0; max-width: none; font-size: 11pt; }
+  section { box-shadow: none; border: none; border-bottom: 1px solid #999; border-radius: 0; break-inside: avoid; page-break-inside: avoid; }
+  .bar { border: 1px solid #999; background: #fff; }
+  .bar span { background: #555; -webkit-print-color-adjust: exact; print-color-adjust: exact; }
+  .chip, .due { border: 1px solid #999; background: #fff; color: #000; }
+  a { color: #000; }
+}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<span class="muted">{{.Done}}/{{.Total}} done, generated {{.Generated}}</span>
+</header>
+<div class="progress"><div class="bar"><span style="width: {{.Percent}}%"></span></div><span>{{.Percent}}%</span></div>
+{{range .Projects}}<section>
+<h2>{{.Name}}</h2>
+<div class="progress"><div class="bar"><span style="width: {{.Percent}}%"></span></div><span class="muted">{{.Done}}/{{.Total}}</span></div>
+{{if .Notes}}<div class="notes">{{.Notes}}</div>
+{{end}}<ul class="tasks">
+{{range .Tasks}}<li class="{{.Class}}"><span class="state">{{.Status}}</span> {{if .Tag}}{{.Tag}} {{end}}<span class="name">{{.Name}}</span>{{range .Tags}}<span class="chip">#{{.}}</span>{{end}}{{if .Due}}<span class="due {{.DueClass}}">📅 {{.Due}}</span>{{end}}{{if .Completed}} <span class="muted">✅ {{.Completed}}</span>{{end}}
+{{if .Notes}}<div class="notes">{{.Notes}}</div>
+{{end}}</li>
+{{end}}</ul>
+</section>
+{{end}}</body>
+</html>
+`))
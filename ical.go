@@ -0,0 +1,243 @@
+package mdtodo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tasks are written as RFC 5545 VTODOs, the UID is the task id kept in the
+// file so changes made in a calendar can be merged back, even renames
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+	icalUIDHost  = "@mdtodo"
+)
+
+var icalHashTag = regexp.MustCompile(`(?:^|\s)#([^\s#]+)`)
+
+// ICalTodo is a VTODO read from a calendar
+type ICalTodo struct {
+	UID         string
+	Summary     string
+	Description string
+	Status      string
+	Due         time.Time
+	Completed   time.Time
+}
+
+var icalStatus = map[Status]string{
+	StatusOpen:      "NEEDS-ACTION",
+	StatusDeferred:  "NEEDS-ACTION",
+	StatusDoing:     "IN-PROCESS",
+	StatusDone:      "COMPLETED",
+	StatusCancelled: "CANCELLED",
+}
+
+// priorities map on the 1 to 9 scale, normal tasks get none
+var icalPriority = map[int]int{0: 1, 1: 2, 2: 3, 4: 7, 5: 9}
+
+func icalEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+func icalUnescape(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// icalSummary is the task name without the due date and completion stamp,
+// those have their own properties
+func icalSummary(t *Task) string {
+	name := DoneRegex.ReplaceAllString(t.Name, "")
+	name = dueRegex.ReplaceAllString(name, "")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// icalLine folds content lines longer than 75 octets, without splitting
+// a character
+func icalLine(w *bufio.Writer, line string) {
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+// WriteICal writes a calendar with a VTODO for every task, keep picks the
+// tasks, nil keeps all
+func WriteICal(w io.Writer, ps Projects, keep func(*Task) bool) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(icalDateTime)
+	ps.AssignIDs()
+
+	icalLine(bw, "BEGIN:VCALENDAR")
+	icalLine(bw, "VERSION:2.0")
+	icalLine(bw, fmt.Sprintf("PRODID:-//%s//%s %s//EN", ApplicationName, ApplicationName, ApplicationVersion))
+	for _, p := range ps.Items {
+		for _, t := range p.Tasks.Items {
+			if keep != nil && !keep(t) {
+				continue
+			}
+			icalLine(bw, "BEGIN:VTODO")
+			icalLine(bw, "UID:"+t.ID+icalUIDHost)
+			icalLine(bw, "DTSTAMP:"+stamp)
+			icalLine(bw, "SUMMARY:"+icalEscape(icalSummary(t)))
+			if t.Notes != "" {
+				icalLine(bw, "DESCRIPTION:"+icalEscape(t.Notes))
+			}
+			categories := []string{icalEscape(p.Name)}
+			if t.Tag != "" {
+				categories = append(categories, icalEscape(t.Tag))
+			}
+			for _, m := range icalHashTag.FindAllStringSubmatch(t.Name, -1) {
+				categories = append(categories, icalEscape(m[1]))
+			}
+			icalLine(bw, "CATEGORIES:"+strings.Join(categories, ","))
+			if s, ok := icalStatus[t.Status]; ok {
+				icalLine(bw, "STATUS:"+s)
+			} else if t.Status.IsDone() {
+				icalLine(bw, "STATUS:COMPLETED")
+			}
+			if prio, ok := icalPriority[t.Priority()]; ok {
+				icalLine(bw, fmt.Sprintf("PRIORITY:%d", prio))
+			}
+			if d, ok := t.Due(); ok {
+				icalLine(bw, "DUE;VALUE=DATE:"+d.Format(icalDate))
+			}
+			if d, ok := t.Completed(); ok && t.Status.IsDone() {
+				icalLine(bw, "COMPLETED:"+d.UTC().Format(icalDateTime))
+			}
+			icalLine(bw, "END:VTODO")
+		}
+	}
+	icalLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+func parseICalTime(value string) (time.Time, error) {
+	if t, err := time.Parse(icalDateTime, value); err == nil {
+		return t.Local(), nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(icalDate, value, time.Local)
+}
+
+// ReadICal reads the VTODOs of a calendar, other components are skipped
+func ReadICal(r io.Reader) ([]ICalTodo, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var todos []ICalTodo
+	var todo *ICalTodo
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+		switch {
+		case name == "BEGIN" && value == "VTODO":
+			todo = &ICalTodo{}
+		case todo == nil:
+		case name == "END" && value == "VTODO":
+			todos = append(todos, *todo)
+			todo = nil
+		case name == "UID":
+			todo.UID = value
+		case name == "SUMMARY":
+			todo.Summary = icalUnescape(value)
+		case name == "DESCRIPTION":
+			todo.Description = icalUnescape(value)
+		case name == "STATUS":
+			todo.Status = strings.ToUpper(value)
+		case name == "DUE" || name == "COMPLETED":
+			t, err := parseICalTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if name == "DUE" {
+				todo.Due = t
+			} else {
+				todo.Completed = t
+			}
+		}
+	}
+	return todos, nil
+}
+
+// MergeICal updates the tasks whose id matches the UID of a todo, it
+// returns how many matched. The id stays when the summary or due date
+// changes the name.
+func (ps *Projects) MergeICal(todos []ICalTodo) int {
+	tasks := map[string]*Task{}
+	for t, id := range ps.TaskIDs() {
+		tasks[id+icalUIDHost] = t
+	}
+
+	matched := 0
+	for _, todo := range todos {
+		t := tasks[todo.UID]
+		if t == nil {
+			continue
+		}
+		matched++
+		// the calendar knows the task by its id now
+		t.KeepID = true
+
+		due, hasDue := t.Due()
+		dueChanged := hasDue != !todo.Due.IsZero() || (hasDue && !due.Equal(todo.Due))
+		if todo.Summary != "" && (todo.Summary != icalSummary(t) || dueChanged) {
+			name := todo.Summary
+			if !todo.Due.IsZero() {
+				name += " 📅 " + todo.Due.Format(DateLayout)
+			}
+			if m := DoneRegex.FindString(t.Name); m != "" {
+				name += m
+			}
+			t.Name = name
+		}
+		t.Notes = todo.Description
+
+		switch todo.Status {
+		case "COMPLETED":
+			if !t.Status.IsDone() {
+				t.SetStatus(StatusDone)
+				if !todo.Completed.IsZero() {
+					t.Name = DoneRegex.ReplaceAllString(t.Name, "") + " " + DoneMark + " " + todo.Completed.Format(DateLayout)
+				}
+			}
+		case "CANCELLED":
+			t.SetStatus(StatusCancelled)
+		case "IN-PROCESS":
+			t.SetStatus(StatusDoing)
+		case "NEEDS-ACTION":
+			if t.Status != StatusDeferred {
+				t.SetStatus(StatusOpen)
+			}
+		}
+	}
+	return matched
+}
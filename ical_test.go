@@ -0,0 +1,104 @@
+package mdtodo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalRoundTrip(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] 🔥 call #work back 📅 2026-01-02\n  a note, more\n- [x] done ✅ 2026-01-01\n")
+	var buf bytes.Buffer
+	if err := WriteICal(&buf, ps, nil); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"SUMMARY:call #work back\r\n", "CATEGORIES:Main,🔥,work\r\n", "DUE;VALUE=DATE:20260102\r\n", "DESCRIPTION:a note\\, more\r\n", "STATUS:COMPLETED\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+
+	todos, err := ReadICal(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 2 {
+		t.Fatalf("read %d todos", len(todos))
+	}
+	if todos[0].Summary != "call #work back" || todos[0].Description != "a note, more" || !todos[0].Due.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("first todo %+v", todos[0])
+	}
+	if n := ps.MergeICal(todos); n != 2 {
+		t.Errorf("%d matched", n)
+	}
+	if got := ps.Items[0].Tasks.Items[0].Name; got != "call #work back 📅 2026-01-02" {
+		t.Errorf("merging the unchanged calendar changed the name to %q", got)
+	}
+}
+
+func TestMergeICal(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] write report\n- [ ] other\n")
+	var buf bytes.Buffer
+	if err := WriteICal(&buf, ps, nil); err != nil {
+		t.Fatal(err)
+	}
+	todos, err := ReadICal(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todos[0].Status = "COMPLETED"
+	todos[0].Completed = time.Date(2026, 3, 4, 12, 0, 0, 0, time.Local)
+	todos[0].Description = "sent"
+	todos = append(todos, ICalTodo{UID: "unknown@mdtodo", Summary: "elsewhere"})
+
+	if n := ps.MergeICal(todos); n != 2 {
+		t.Errorf("%d matched, the unknown UID should not", n)
+	}
+	task := ps.Items[0].Tasks.Items[0]
+	if task.Status != StatusDone || task.Notes != "sent" || task.Name != "write report ✅ 2026-03-04" {
+		t.Errorf("got %q %q notes %q", task.Status, task.Name, task.Notes)
+	}
+	if len(ps.Items[0].Tasks.Items) != 2 {
+		t.Error("merge added a task")
+	}
+}
+
+func TestICalUIDSurvivesMerge(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] write report 📅 2026-01-02\n")
+	var first bytes.Buffer
+	if err := WriteICal(&first, ps, nil); err != nil {
+		t.Fatal(err)
+	}
+	todos, err := ReadICal(strings.NewReader(first.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := todos[0].UID
+	todos[0].Summary = "write the report"
+	todos[0].Due = time.Date(2026, 2, 3, 0, 0, 0, 0, time.Local)
+	if n := ps.MergeICal(todos); n != 1 {
+		t.Fatalf("%d matched", n)
+	}
+
+	// saved and read again, the calendar must still find the task
+	ps = readProjects(t, ps.String())
+	var second bytes.Buffer
+	if err := WriteICal(&second, ps, nil); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadICal(&second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].UID != uid {
+		t.Errorf("UID %s became %s after the edit", uid, again[0].UID)
+	}
+	if again[0].Summary != "write the report" || !again[0].Due.Equal(todos[0].Due) {
+		t.Errorf("edit not kept: %+v", again[0])
+	}
+	if n := ps.MergeICal(todos); n != 1 {
+		t.Errorf("the edited calendar matches %d tasks", n)
+	}
+}
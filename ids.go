@@ -0,0 +1,149 @@
+package mdtodo
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tasks and projects get an id made from their name when they are read, it
+// stays while they are renamed, moved or change state until the file is
+// read again. Once an id is handed out to a program that keeps it, the API
+// server or a calendar, it is kept in the file so it survives the next read
+// too: a task has an obsidian tasks style field,
+// `- [ ] call back 🆔 3f2a9c01b4`, a project a comment after the heading,
+// `## Home <!-- id: 9e1d0c2a7b -->`. Ids that are in the file stay there.
+
+const IDMark = "🆔"
+
+var (
+	idRegex        = regexp.MustCompile(`\s*` + IDMark + `\s*([\w-]+)`)
+	projectIDRegex = regexp.MustCompile(`\s*<!-- id: ([\w-]+) -->`)
+)
+
+func shortHash(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:5])
+}
+
+// splitID takes the id field out of a task name
+func splitID(name string) (string, string) {
+	m := idRegex.FindStringSubmatch(name)
+	if m == nil {
+		return name, ""
+	}
+	return strings.TrimSpace(idRegex.ReplaceAllString(name, "")), m[1]
+}
+
+// splitProjectID takes the id comment out of a project heading
+func splitProjectID(heading string) (string, string) {
+	m := projectIDRegex.FindStringSubmatch(heading)
+	if m == nil {
+		return heading, ""
+	}
+	return strings.TrimSpace(projectIDRegex.ReplaceAllString(heading, "")), m[1]
+}
+
+// newID is the hash of base, or of base and a counter when that is taken
+func newID(used map[string]bool, base string) string {
+	id := shortHash(base)
+	for n := 2; used[id]; n++ {
+		id = shortHash(fmt.Sprintf("%s\n%d", base, n))
+	}
+	used[id] = true
+	return id
+}
+
+// AssignIDs gives an id to the projects and tasks without one, and to those
+// whose id an earlier one has, eg a copied task. New ids are made from the
+// name without the completion stamp and tasks with the same name are told
+// apart by their order, so a file without ids gets the same ones every time
+// it is read.
+func (ps Projects) AssignIDs() {
+	projects, tasks := map[string]bool{}, map[string]bool{}
+	for _, p := range ps.Items {
+		if projects[p.ID] {
+			p.ID = ""
+		} else if p.ID != "" {
+			projects[p.ID] = true
+		}
+		for _, t := range p.Tasks.Items {
+			if tasks[t.ID] {
+				t.ID = ""
+			} else if t.ID != "" {
+				tasks[t.ID] = true
+			}
+		}
+	}
+	for _, p := range ps.Items {
+		if p.ID == "" {
+			p.ID = newID(projects, "project\n"+p.Name)
+		}
+		for _, t := range p.Tasks.Items {
+			if t.ID == "" {
+				t.ID = newID(tasks, strings.TrimSpace(DoneRegex.ReplaceAllString(t.Name, "")))
+			}
+		}
+	}
+}
+
+// KeepIDs has every id written to the file from the next save on, for ids
+// handed out to other programs. It reports if any was not kept yet.
+func (ps Projects) KeepIDs() bool {
+	ps.AssignIDs()
+	changed := false
+	for _, p := range ps.Items {
+		changed = changed || !p.KeepID
+		p.KeepID = true
+		for _, t := range p.Tasks.Items {
+			changed = changed || !t.KeepID
+			t.KeepID = true
+		}
+	}
+	return changed
+}
+
+// ProjectID is the id of the project, see AssignIDs
+func ProjectID(p *Project) string {
+	if p.ID == "" {
+		p.ID = shortHash("project\n" + p.Name)
+	}
+	return p.ID
+}
+
+// TaskIDs maps every task to its id, tasks without one get it first
+func (ps Projects) TaskIDs() map[*Task]string {
+	ps.AssignIDs()
+	ids := map[*Task]string{}
+	for _, p := range ps.Items {
+		for _, t := range p.Tasks.Items {
+			ids[t] = t.ID
+		}
+	}
+	return ids
+}
+
+// FindTask returns the task with the id and its project
+func (ps Projects) FindTask(id string) (*Project, *Task) {
+	ps.AssignIDs()
+	for _, p := range ps.Items {
+		for _, t := range p.Tasks.Items {
+			if t.ID == id {
+				return p, t
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (ps Projects) FindProject(id string) *Project {
+	ps.AssignIDs()
+	for _, p := range ps.Items {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
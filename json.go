@@ -0,0 +1,236 @@
+package mdtodo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON format. It goes up when a field
+// changes meaning or is removed, new fields keep the version.
+const SchemaVersion = 1
+
+// JSONExport is the JSON form of a todo file
+type JSONExport struct {
+	Version  int           `json:"version"`
+	Projects []JSONProject `json:"projects"`
+}
+
+type JSONProject struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Notes string     `json:"notes,omitempty"`
+	File  string     `json:"file,omitempty"`
+	Tasks []JSONTask `json:"tasks"`
+}
+
+// JSONTask is a task, Due, Completed and Priority are read from the name
+// and ignored on import
+type JSONTask struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Tag       string `json:"tag,omitempty"`
+	Status    string `json:"status"`
+	Notes     string `json:"notes,omitempty"`
+	Due       string `json:"due,omitempty"`
+	Completed string `json:"completed,omitempty"`
+	Priority  int    `json:"priority"`
+}
+
+//---------Export and import-----------------------------
+
+func newJSONTask(t *Task) JSONTask {
+	jt := JSONTask{ID: t.ID, Name: t.Name, Tag: t.Tag, Status: t.Status.Name(), Notes: t.Notes, Priority: t.Priority()}
+	if d, ok := t.Due(); ok {
+		jt.Due = d.Format(DateLayout)
+	}
+	if d, ok := t.Completed(); ok {
+		jt.Completed = d.Format(DateLayout)
+	}
+	return jt
+}
+
+func newJSONProject(p *Project) JSONProject {
+	jp := JSONProject{ID: p.ID, Name: p.Name, Notes: p.Notes, File: p.File, Tasks: []JSONTask{}}
+	for _, t := range p.Tasks.Items {
+		jp.Tasks = append(jp.Tasks, newJSONTask(t))
+	}
+	return jp
+}
+
+func (ps Projects) ToJSON() JSONExport {
+	ps.AssignIDs()
+	e := JSONExport{Version: SchemaVersion, Projects: []JSONProject{}}
+	for _, p := range ps.Items {
+		e.Projects = append(e.Projects, newJSONProject(p))
+	}
+	return e
+}
+
+// ProjectJSON is one of the projects with its tasks in the export schema,
+// for answers about a single project
+func (ps Projects) ProjectJSON(p *Project) JSONProject {
+	ps.AssignIDs()
+	return newJSONProject(p)
+}
+
+// TaskJSON is one of the tasks in the export schema
+func (ps Projects) TaskJSON(t *Task) JSONTask {
+	ps.AssignIDs()
+	return newJSONTask(t)
+}
+
+// NotFoundError is an id that is not in the document
+type NotFoundError struct {
+	What string
+	ID   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no %s with id %s", e.What, e.ID)
+}
+
+// JSONTaskEdit is a change to a task as the HTTP API and plugins take it,
+// Project is the id of the project to move the task to
+type JSONTaskEdit struct {
+	Name    *string `json:"name"`
+	Tag     *string `json:"tag"`
+	Notes   *string `json:"notes"`
+	Status  *string `json:"status"`
+	Project *string `json:"project"`
+	Index   *int    `json:"index"`
+}
+
+// TaskEdit checks the status and looks up the project of the edit
+func (je JSONTaskEdit) TaskEdit(ps Projects) (TaskEdit, error) {
+	e := TaskEdit{Name: je.Name, Tag: je.Tag, Notes: je.Notes, Index: je.Index}
+	if je.Status != nil {
+		status, ok := StatusNamed(*je.Status)
+		if !ok {
+			return e, fmt.Errorf("unknown status %q, use %s", *je.Status, strings.Join(StatusNames(), ", "))
+		}
+		e.Status = &status
+	}
+	if je.Project != nil {
+		if e.Project = ps.FindProject(*je.Project); e.Project == nil {
+			return e, &NotFoundError{"project", *je.Project}
+		}
+	}
+	return e, nil
+}
+
+// WriteJSON writes the projects as indented JSON
+func WriteJSON(w io.Writer, ps Projects) error {
+	return ps.ToJSON().Write(w)
+}
+
+func (e JSONExport) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// ReadJSON reads an export, newer schema versions are refused
+func ReadJSON(r io.Reader) (JSONExport, error) {
+	var e JSONExport
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return e, err
+	}
+	if e.Version < 1 || e.Version > SchemaVersion {
+		return e, fmt.Errorf("unsupported schema version %d, this mdtodo reads %d", e.Version, SchemaVersion)
+	}
+	return e, nil
+}
+
+func (jt JSONTask) task() (*Task, error) {
+	status, ok := StatusNamed(jt.Status)
+	if jt.Status == "" {
+		status, ok = StatusOpen, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("task %q: unknown status %q", jt.Name, jt.Status)
+	}
+	return &Task{Status: status, Name: jt.Name, Tag: jt.Tag, Notes: jt.Notes, ID: jt.ID}, nil
+}
+
+// ToProjects turns the export back into projects
+func (e JSONExport) ToProjects() (Projects, error) {
+	ps := NewProjects()
+	for _, jp := range e.Projects {
+		p := NewProject(jp.Name)
+		p.Notes = jp.Notes
+		p.File = jp.File
+		p.ID = jp.ID
+		for _, jt := range jp.Tasks {
+			t, err := jt.task()
+			if err != nil {
+				return ps, err
+			}
+			p.Tasks.Add(t)
+		}
+		ps.Add(p)
+	}
+	ps.AssignIDs()
+	return ps, nil
+}
+
+// Merge adds an export to the projects. Projects and tasks with a known id
+// are updated, a task whose project changed is moved, the rest is added and
+// nothing is removed.
+func (ps *Projects) Merge(e JSONExport) error {
+	tasks := map[string]*Task{}
+	owner := map[*Task]*Project{}
+	for t, id := range ps.TaskIDs() {
+		tasks[id] = t
+	}
+	projects := map[string]*Project{}
+	for _, p := range ps.Items {
+		projects[ProjectID(p)] = p
+		for _, t := range p.Tasks.Items {
+			owner[t] = p
+		}
+	}
+
+	for _, jp := range e.Projects {
+		p := projects[jp.ID]
+		if p == nil {
+			for _, other := range ps.Items {
+				if other.Name == jp.Name {
+					p = other
+				}
+			}
+		}
+		if p == nil {
+			p = NewProject(jp.Name)
+			p.File = jp.File
+			p.ID = jp.ID
+			ps.Items = append(ps.Items, p)
+		}
+		p.Name = jp.Name
+		if jp.Notes != "" {
+			p.Notes = jp.Notes
+		}
+
+		for _, jt := range jp.Tasks {
+			in, err := jt.task()
+			if err != nil {
+				return err
+			}
+			t := tasks[jt.ID]
+			if t == nil {
+				p.Tasks.Items = append(p.Tasks.Items, in)
+				continue
+			}
+			t.Status, t.Name, t.Tag, t.Notes = in.Status, in.Name, in.Tag, in.Notes
+			if from := owner[t]; from != p {
+				from.Tasks.Remove(t)
+				p.Tasks.Items = append(p.Tasks.Items, t)
+				owner[t] = p
+			}
+		}
+	}
+	// ids of another file may be taken here already
+	ps.AssignIDs()
+	return nil
+}
@@ -0,0 +1,123 @@
+package mdtodo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func readProjects(t *testing.T, md string) Projects {
+	t.Helper()
+	ps, err := ReadFrom(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
+
+func TestTaskIDs(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] same\n- [ ] same\n- [ ] other\n")
+	ids := ps.TaskIDs()
+	tasks := ps.Items[0].Tasks.Items
+	seen := map[string]bool{}
+	for _, task := range tasks {
+		id := ids[task]
+		if id == "" || seen[id] {
+			t.Fatalf("id %q of %q is empty or taken", id, task.Name)
+		}
+		seen[id] = true
+	}
+
+	id, projectID := ids[tasks[2]], ProjectID(ps.Items[0])
+	tasks[2].SetStatus(StatusDone)
+	tasks[2].Name = "renamed 📅 2026-05-06"
+	ps.Items[0].Name = "Renamed"
+	if got := ps.TaskIDs()[tasks[2]]; got != id {
+		t.Errorf("editing the task changed its id from %s to %s", id, got)
+	}
+	if p, task := ps.FindTask(id); p != ps.Items[0] || task != tasks[2] {
+		t.Errorf("FindTask(%s) = %v, %v", id, p, task)
+	}
+
+	// ids are only written once they are kept, a copied one is given a new id
+	if strings.Contains(ps.String(), IDMark) || strings.Contains(ps.String(), "<!-- id:") {
+		t.Errorf("ids are written without being kept:\n%s", ps)
+	}
+	if !ps.KeepIDs() || ps.KeepIDs() {
+		t.Error("KeepIDs does not report the ids it keeps")
+	}
+	ps.Items[0].Tasks.Items = append(ps.Items[0].Tasks.Items, &Task{Status: StatusOpen, Name: "copy", ID: id, KeepID: true})
+	again := readProjects(t, ps.String())
+	if got := ProjectID(again.Items[0]); got != projectID {
+		t.Errorf("project id %s read back as %s", projectID, got)
+	}
+	for i, task := range again.Items[0].Tasks.Items[:3] {
+		if task.ID != ids[tasks[i]] || task.Name != tasks[i].Name {
+			t.Errorf("task %d read back as %q %s, want %q %s", i, task.Name, task.ID, tasks[i].Name, ids[tasks[i]])
+		}
+	}
+	if copied := again.Items[0].Tasks.Items[3]; copied.ID == id || copied.ID == "" {
+		t.Errorf("the copy has id %q", copied.ID)
+	}
+	if !strings.Contains(ps.String(), "## Renamed <!-- id: "+projectID+" -->\n") || !strings.Contains(ps.String(), "- [x] renamed 📅 2026-05-06 🆔 "+id+"\n") {
+		t.Errorf("ids are not written as expected:\n%s", ps)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\nproject notes\n- [ ] 🔥 hot ⏫ 📅 2026-01-02\n  a note\n- [x] done ✅ 2026-01-01\n\n## Later\n- [/] doing\n")
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, ps); err != nil {
+		t.Fatal(err)
+	}
+	e, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jt := e.Projects[0].Tasks[0]
+	if jt.Tag != "🔥" || jt.Due != "2026-01-02" || jt.Status != "open" || jt.Notes != "a note" {
+		t.Errorf("first task %+v", jt)
+	}
+	if c := e.Projects[0].Tasks[1].Completed; c != "2026-01-01" {
+		t.Errorf("completed %q", c)
+	}
+	back, err := e.ToProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.String() != ps.String() {
+		t.Errorf("round trip changed the file:\n%s\n---\n%s", ps, back)
+	}
+}
+
+func TestReadJSONNewerVersion(t *testing.T) {
+	if _, err := ReadJSON(strings.NewReader(`{"version": 99, "projects": []}`)); err == nil {
+		t.Error("a newer schema version was read")
+	}
+}
+
+func TestMergeJSON(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] keep\n- [ ] move\n")
+	e := ps.ToJSON()
+	moved := e.Projects[0].Tasks[1]
+	moved.Status = "done"
+	e.Projects[0].Tasks = e.Projects[0].Tasks[:1]
+	e.Projects = append(e.Projects, JSONProject{Name: "Other", Tasks: []JSONTask{moved, {Name: "new"}}})
+
+	if err := ps.Merge(e); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range ps.Items {
+		got = append(got, p.Name)
+		for _, task := range p.Tasks.Items {
+			got = append(got, string(task.Status)+task.Name)
+		}
+	}
+	if want := "Main, keep,Other,xmove, new"; strings.Join(got, ",") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, ","), want)
+	}
+	if _, task := ps.FindTask(moved.ID); task == nil || task.Name != "move" {
+		t.Errorf("the moved task lost its id %s", moved.ID)
+	}
+}
@@ -0,0 +1,73 @@
+package mdtodo
+
+import (
+	"regexp"
+)
+
+// The common inline markdown of notes, for the details pane and the html
+// page. It is meant for reading and does not try to be complete, the
+// pieces do not nest.
+
+// InlineKind is what a piece of a line of notes is
+type InlineKind int
+
+const (
+	InlineText InlineKind = iota
+	InlineBold
+	InlineItalic
+	InlineCode
+	InlineLink
+)
+
+// Inline is a piece of a line, Link is the target of a link
+type Inline struct {
+	Kind InlineKind
+	Text string
+	Link string
+}
+
+// the patterns in the order they win when they start at the same place,
+// the text is in the first group that matched
+var inlinePatterns = []struct {
+	kind InlineKind
+	re   *regexp.Regexp
+}{
+	{InlineLink, regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)},
+	{InlineCode, regexp.MustCompile("`([^`]+)`")},
+	{InlineBold, regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)},
+	{InlineItalic, regexp.MustCompile(`\*(.+?)\*|\b_(.+?)_\b`)},
+}
+
+// ParseInline splits a line into text and the bold, italic, code and link
+// pieces in it
+func ParseInline(line string) []Inline {
+	var pieces []Inline
+	for line != "" {
+		kind, at := InlineText, []int(nil)
+		for _, p := range inlinePatterns {
+			if loc := p.re.FindStringSubmatchIndex(line); loc != nil && (at == nil || loc[0] < at[0]) {
+				kind, at = p.kind, loc
+			}
+		}
+		if at == nil {
+			pieces = append(pieces, Inline{Kind: InlineText, Text: line})
+			break
+		}
+		if at[0] > 0 {
+			pieces = append(pieces, Inline{Kind: InlineText, Text: line[:at[0]]})
+		}
+		piece := Inline{Kind: kind}
+		for g := 1; g < len(at)/2; g++ {
+			if at[2*g] >= 0 {
+				piece.Text = line[at[2*g]:at[2*g+1]]
+				break
+			}
+		}
+		if kind == InlineLink {
+			piece.Link = line[at[4]:at[5]]
+		}
+		pieces = append(pieces, piece)
+		line = line[at[1]:]
+	}
+	return pieces
+}
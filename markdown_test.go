@@ -0,0 +1,28 @@
+package mdtodo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		line string
+		want []Inline
+	}{
+		{"plain", []Inline{{InlineText, "plain", ""}}},
+		{"a **b** c", []Inline{{InlineText, "a ", ""}, {InlineBold, "b", ""}, {InlineText, " c", ""}}},
+		{"**b** *i*", []Inline{{InlineBold, "b", ""}, {InlineText, " ", ""}, {InlineItalic, "i", ""}}},
+		{"__b__ _i_", []Inline{{InlineBold, "b", ""}, {InlineText, " ", ""}, {InlineItalic, "i", ""}}},
+		{"snake_case_name", []Inline{{InlineText, "snake_case_name", ""}}},
+		{"run `go *test*`", []Inline{{InlineText, "run ", ""}, {InlineCode, "go *test*", ""}}},
+		{"see [docs](https://x.io/a_b_c)", []Inline{{InlineText, "see ", ""}, {InlineLink, "docs", "https://x.io/a_b_c"}}},
+		{"[not](a link)", []Inline{{InlineText, "[not](a link)", ""}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseInline(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseInline(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
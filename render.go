@@ -0,0 +1,192 @@
+package mdtodo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Renderer writes projects in an export format
+type Renderer interface {
+	Render(w io.Writer, ps Projects) error
+}
+
+// RendererFunc makes a Renderer of a plain function
+type RendererFunc func(w io.Writer, ps Projects) error
+
+func (f RendererFunc) Render(w io.Writer, ps Projects) error {
+	return f(w, ps)
+}
+
+var renderers = map[string]Renderer{}
+
+// RegisterRenderer adds an export format under a name, a later one with the
+// same name replaces it
+func RegisterRenderer(name string, r Renderer) {
+	renderers[name] = r
+}
+
+func LookupRenderer(name string) (Renderer, bool) {
+	r, ok := renderers[name]
+	return r, ok
+}
+
+func RendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterRenderer("markdown", RendererFunc(func(w io.Writer, ps Projects) error {
+		_, err := io.WriteString(w, ps.String())
+		return err
+	}))
+	RegisterRenderer("json", RendererFunc(WriteJSON))
+	RegisterRenderer("todotxt", RendererFunc(WriteTodoTxt))
+	RegisterRenderer("ical", RendererFunc(func(w io.Writer, ps Projects) error {
+		return WriteICal(w, ps, nil)
+	}))
+	RegisterRenderer("org", RendererFunc(WriteOrg))
+	RegisterRenderer("gfm", RendererFunc(WriteGFM))
+}
+
+//---------Org-mode-----------------------------
+
+var orgKeywords = map[Status]string{
+	StatusOpen:      "TODO",
+	StatusDoing:     "DOING",
+	StatusDeferred:  "WAITING",
+	StatusDone:      "DONE",
+	StatusCancelled: "CANCELLED",
+}
+
+func orgDate(t time.Time) string {
+	return t.Format("2006-01-02 Mon")
+}
+
+// WriteOrg writes an Org-mode outline, projects are top headings and tasks
+// TODO headings below them with the notes as body text
+func WriteOrg(w io.Writer, ps Projects) error {
+	var sb strings.Builder
+	sb.WriteString("#+TITLE: Todo\n")
+	sb.WriteString("#+TODO: TODO DOING WAITING | DONE CANCELLED\n")
+	sb.WriteString("#+PRIORITIES: A E C\n\n")
+
+	for _, p := range ps.Items {
+		sb.WriteString("* " + p.Name + "\n")
+		if p.Notes != "" {
+			sb.WriteString(p.Notes + "\n")
+		}
+		for _, t := range p.Tasks.Items {
+			keyword, ok := orgKeywords[t.Status]
+			if !ok {
+				keyword = "TODO"
+				if t.Status.IsDone() {
+					keyword = "DONE"
+				}
+			}
+			sb.WriteString("** " + keyword + " ")
+
+			priority, name := priorityLetter(icalSummary(t))
+			if priority != "" {
+				sb.WriteString("[#" + priority + "] ")
+			}
+			if t.Tag != "" {
+				sb.WriteString(t.Tag + " ")
+			}
+			var tags []string
+			for _, m := range hashTagRegex.FindAllStringSubmatch(name, -1) {
+				tags = append(tags, m[2])
+			}
+			sb.WriteString(strings.Join(strings.Fields(hashTagRegex.ReplaceAllString(name, "$1")), " "))
+			if len(tags) > 0 {
+				sb.WriteString(" :" + strings.Join(tags, ":") + ":")
+			}
+			sb.WriteString("\n")
+
+			var planning []string
+			if d, ok := t.Completed(); ok && t.Status.Closed() {
+				planning = append(planning, "CLOSED: ["+orgDate(d)+"]")
+			}
+			if d, ok := t.Due(); ok {
+				planning = append(planning, "DEADLINE: <"+orgDate(d)+">")
+			}
+			if len(planning) > 0 {
+				sb.WriteString("   " + strings.Join(planning, " ") + "\n")
+			}
+			if t.Notes != "" {
+				for _, line := range strings.Split(t.Notes, "\n") {
+					sb.WriteString("   " + line + "\n")
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+//---------GitHub markdown report-----------------------------
+
+func percent(done, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return done * 100 / total
+}
+
+// gfmDetails folds notes into a <details> block, indented to sit inside a
+// list item
+func gfmDetails(sb *strings.Builder, summary, notes, indent string) {
+	sb.WriteString(indent + "<details><summary>" + summary + "</summary>\n\n")
+	for _, line := range strings.Split(notes, "\n") {
+		sb.WriteString(indent + line + "\n")
+	}
+	sb.WriteString("\n" + indent + "</details>\n\n")
+}
+
+// WriteGFM writes a report for pull requests and issues, with the progress
+// of every project and the notes folded away
+func WriteGFM(w io.Writer, ps Projects) error {
+	var sb strings.Builder
+	done, total := 0, 0
+	for _, p := range ps.Items {
+		d, t := p.Progress()
+		done += d
+		total += t
+	}
+	sb.WriteString("# Todo\n\n")
+	sb.WriteString(fmt.Sprintf("**%d/%d done** (%d%%)\n\n", done, total, percent(done, total)))
+
+	for _, p := range ps.Items {
+		d, t := p.Progress()
+		sb.WriteString(fmt.Sprintf("## %s (%d/%d, %d%%)\n\n", p.Name, d, t, percent(d, t)))
+		if p.Notes != "" {
+			gfmDetails(&sb, "Notes", p.Notes, "")
+		}
+		for _, t := range p.Tasks.Items {
+			name := strings.TrimSpace(t.Tag + " " + t.Name)
+			switch {
+			case t.Status.IsDone():
+				sb.WriteString("- [x] " + name + "\n")
+			case t.Status == StatusCancelled:
+				sb.WriteString("- [ ] ~~" + name + "~~\n")
+			case t.Status == StatusOpen:
+				sb.WriteString("- [ ] " + name + "\n")
+			default:
+				sb.WriteString("- [ ] " + name + " _(" + t.Status.Name() + ")_\n")
+			}
+			if t.Notes != "" {
+				gfmDetails(&sb, "Notes", t.Notes, "  ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
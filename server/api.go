@@ -0,0 +1,185 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// the answers use the JSON export schema, see mdtodo.JSONExport
+
+func (s *Server) project(id string) (*mdtodo.Project, error) {
+	if p := s.doc.Projects.FindProject(id); p != nil {
+		return p, nil
+	}
+	return nil, &mdtodo.NotFoundError{What: "project", ID: id}
+}
+
+func (s *Server) task(id string) (*mdtodo.Project, *mdtodo.Task, error) {
+	if p, t := s.doc.Projects.FindTask(id); t != nil {
+		return p, t, nil
+	}
+	return nil, nil, &mdtodo.NotFoundError{What: "task", ID: id}
+}
+
+// taskEdit checks the body, an unknown project is not found and anything
+// else a bad request
+func (s *Server) taskEdit(body mdtodo.JSONTaskEdit) (mdtodo.TaskEdit, error) {
+	edit, err := body.TaskEdit(s.doc.Projects)
+	var nf *mdtodo.NotFoundError
+	if err != nil && !errors.As(err, &nf) {
+		return edit, badRequest("%v", err)
+	}
+	return edit, err
+}
+
+//---------Projects-----------------------------
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	s.read(w, func() (any, error) {
+		return s.doc.Projects.ToJSON(), nil
+	})
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	s.read(w, func() (any, error) {
+		p, err := s.project(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		return s.doc.Projects.ProjectJSON(p), nil
+	})
+}
+
+type projectBody struct {
+	Name  *string `json:"name"`
+	Notes *string `json:"notes"`
+}
+
+func (s *Server) addProject(w http.ResponseWriter, r *http.Request) {
+	var body projectBody
+	if err := decode(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.Name == nil || strings.TrimSpace(*body.Name) == "" {
+		writeError(w, badRequest("a project needs a name"))
+		return
+	}
+	s.change(w, http.StatusCreated, func() (func() any, error) {
+		p := s.doc.AddProject(strings.TrimSpace(*body.Name))
+		if body.Notes != nil {
+			s.doc.EditProject(p, nil, body.Notes)
+		}
+		return func() any { return s.doc.Projects.ProjectJSON(p) }, nil
+	})
+}
+
+func (s *Server) editProject(w http.ResponseWriter, r *http.Request) {
+	var body projectBody
+	if err := decode(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.change(w, http.StatusOK, func() (func() any, error) {
+		p, err := s.project(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		s.doc.EditProject(p, body.Name, body.Notes)
+		return func() any { return s.doc.Projects.ProjectJSON(p) }, nil
+	})
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	s.change(w, http.StatusOK, func() (func() any, error) {
+		p, err := s.project(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		s.doc.RemoveProject(p)
+		return nil, nil
+	})
+}
+
+//---------Tasks-----------------------------
+
+func (s *Server) addTask(w http.ResponseWriter, r *http.Request) {
+	var body mdtodo.JSONTaskEdit
+	if err := decode(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	if body.Name == nil || strings.TrimSpace(*body.Name) == "" {
+		writeError(w, badRequest("a task needs a name"))
+		return
+	}
+	s.change(w, http.StatusCreated, func() (func() any, error) {
+		p, err := s.project(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		edit, err := s.taskEdit(body)
+		if err != nil {
+			return nil, err
+		}
+		t := s.doc.AddTask(p, *body.Name, edit)
+		return func() any { return s.doc.Projects.TaskJSON(t) }, nil
+	})
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	s.read(w, func() (any, error) {
+		_, t, err := s.task(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		return s.doc.Projects.TaskJSON(t), nil
+	})
+}
+
+// editTask changes the fields given, a project id moves the task there and
+// an index moves it inside its project
+func (s *Server) editTask(w http.ResponseWriter, r *http.Request) {
+	var body mdtodo.JSONTaskEdit
+	if err := decode(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.change(w, http.StatusOK, func() (func() any, error) {
+		p, t, err := s.task(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		edit, err := s.taskEdit(body)
+		if err != nil {
+			return nil, err
+		}
+		s.doc.EditTask(p, t, edit)
+		return func() any { return s.doc.Projects.TaskJSON(t) }, nil
+	})
+}
+
+func (s *Server) toggleTask(w http.ResponseWriter, r *http.Request) {
+	s.change(w, http.StatusOK, func() (func() any, error) {
+		p, t, err := s.task(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		s.doc.SetStatus(p, t, s.opts.Next(t.Status))
+		return func() any { return s.doc.Projects.TaskJSON(t) }, nil
+	})
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	s.change(w, http.StatusOK, func() (func() any, error) {
+		p, t, err := s.task(r.PathValue("id"))
+		if err != nil {
+			return nil, err
+		}
+		s.doc.RemoveTask(p, t)
+		return nil, nil
+	})
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// change is what the event stream sends for every document event, the ids
+// are those after the change so a removed task only has its name
+type change struct {
+	Kind     string `json:"kind"`
+	Project  string `json:"project,omitempty"`
+	Task     string `json:"task,omitempty"`
+	TaskName string `json:"task_name,omitempty"`
+}
+
+// onEvent runs inside Do, or on the goroutine of whoever changed the
+// document, and hands the change to every client
+func (s *Server) onEvent(e mdtodo.Event) {
+	if e.Kind == mdtodo.EventSaved || e.Kind == mdtodo.EventLoaded {
+		s.modTime = fileTime(s.doc.Filename)
+	}
+	c := change{Kind: e.Kind.String()}
+	if e.Project != nil {
+		c.Project = mdtodo.ProjectID(e.Project)
+	}
+	if e.Task != nil {
+		s.doc.Projects.AssignIDs()
+		c.Task = e.Task.ID
+		c.TaskName = e.Task.Name
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	for ch := range s.clients {
+		// a client that does not keep up misses events rather than
+		// holding up the document
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// events streams the changes as server-sent events until the client goes
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	ch := make(chan []byte, 32)
+	s.clientsMu.Lock()
+	s.clients[ch] = true
+	s.clientsMu.Unlock()
+	defer func() {
+		s.clientsMu.Lock()
+		delete(s.clients, ch)
+		s.clientsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
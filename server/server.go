@@ -0,0 +1,348 @@
+// Package server serves a todo document over HTTP, a REST API for the
+// projects and tasks, a server-sent-events stream of the changes and a small
+// web ui using both.
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// Options connects the server to the program that owns the document
+type Options struct {
+	// Do runs a read or change of the document, one at a time. The default
+	// holds a lock, the tui runs them on its own goroutine.
+	Do func(func() error) error
+	// Save writes the document after a change, Document.Save by default
+	Save func() error
+	// Reload reads the file again after it changed on disk, Document.Load
+	// by default
+	Reload func() error
+	// Next is the state a task toggles to, open and done by default
+	Next func(mdtodo.Status) mdtodo.Status
+	// Token is asked of every API request, as `Authorization: Bearer` or a
+	// token parameter. Listening on anything but loopback needs one, a
+	// random one is made when it is empty.
+	Token string
+}
+
+type Server struct {
+	doc  *mdtodo.Document
+	opts Options
+	mu   sync.Mutex
+
+	// modTime is when the file was last read or written by us, a newer
+	// one means somebody else changed it
+	modTime time.Time
+
+	// addr is where Listen listens, host the name it was given, which the
+	// Host header may use besides ip addresses and localhost
+	addr string
+	host string
+
+	clientsMu sync.Mutex
+	clients   map[chan []byte]bool
+}
+
+// New makes a server for the document, it listens to its events
+func New(d *mdtodo.Document, opts Options) *Server {
+	s := &Server{doc: d, opts: opts, clients: map[chan []byte]bool{}}
+	if s.opts.Do == nil {
+		s.opts.Do = func(f func() error) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return f()
+		}
+	}
+	if s.opts.Save == nil {
+		s.opts.Save = d.Save
+	}
+	if s.opts.Reload == nil {
+		s.opts.Reload = d.Load
+	}
+	if s.opts.Next == nil {
+		s.opts.Next = func(st mdtodo.Status) mdtodo.Status {
+			if st.Closed() {
+				return mdtodo.StatusOpen
+			}
+			return mdtodo.StatusDone
+		}
+	}
+	s.modTime = fileTime(d.Filename)
+	d.OnEvent(s.onEvent)
+	return s
+}
+
+func fileTime(filename string) time.Time {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// ListenAndServe serves the API on addr and watches the file for changes
+// made by other programs
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := s.Listen(addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Listen opens addr for Serve, and makes a token when it is not loopback
+// and Options.Token is empty. URL has the address to open then.
+func (s *Server) Listen(addr string) (net.Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil && net.ParseIP(host) == nil {
+		s.host = host
+	}
+	if err := s.listening(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// listening notes the address of l and makes sure there is a token when
+// other machines can connect
+func (s *Server) listening(l net.Listener) error {
+	s.addr = l.Addr().String()
+	loopback := false
+	if tcp, ok := l.Addr().(*net.TCPAddr); ok {
+		loopback = tcp.IP.IsLoopback()
+	}
+	if loopback || s.opts.Token != "" {
+		return nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	s.opts.Token = hex.EncodeToString(b)
+	return nil
+}
+
+// URL is the address of the web ui, with the token when there is one
+func (s *Server) URL() string {
+	host := s.addr
+	if s.host != "" {
+		_, port, _ := net.SplitHostPort(s.addr)
+		host = net.JoinHostPort(s.host, port)
+	}
+	u := "http://" + host + "/"
+	if s.opts.Token != "" {
+		u += "?token=" + url.QueryEscape(s.opts.Token)
+	}
+	return u
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	if s.addr == "" {
+		if err := s.listening(l); err != nil {
+			return err
+		}
+	}
+	go s.watch()
+	return http.Serve(l, s.Handler())
+}
+
+// Handler serves the API and the web ui, for programs that run their own
+// http.Server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/projects", s.listProjects)
+	mux.HandleFunc("POST /api/projects", s.addProject)
+	mux.HandleFunc("GET /api/projects/{id}", s.getProject)
+	mux.HandleFunc("PATCH /api/projects/{id}", s.editProject)
+	mux.HandleFunc("DELETE /api/projects/{id}", s.deleteProject)
+	mux.HandleFunc("POST /api/projects/{id}/tasks", s.addTask)
+	mux.HandleFunc("GET /api/tasks/{id}", s.getTask)
+	mux.HandleFunc("PATCH /api/tasks/{id}", s.editTask)
+	mux.HandleFunc("POST /api/tasks/{id}/toggle", s.toggleTask)
+	mux.HandleFunc("DELETE /api/tasks/{id}", s.deleteTask)
+	mux.HandleFunc("GET /api/events", s.events)
+	mux.Handle("GET /", webHandler())
+	return s.guard(mux)
+}
+
+// guard refuses what a page of another site could do through the browser.
+// The Host has to be one a rebinding dns name cannot be, the API needs the
+// token when there is one, and changes have to come from the same origin
+// and be JSON, which a form cannot send and a script only after asking.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, &httpError{http.StatusForbidden, "host " + r.Host + " is not allowed"})
+			return
+		}
+		if s.opts.Token != "" && strings.HasPrefix(r.URL.Path, "/api/") && !s.hasToken(r) {
+			writeError(w, &httpError{http.StatusUnauthorized, "the API needs the token mdtodo serve printed"})
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+				writeError(w, &httpError{http.StatusForbidden, "requests from " + origin + " are not allowed"})
+				return
+			}
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+				writeError(w, &httpError{http.StatusUnsupportedMediaType, "changes need Content-Type: application/json"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports if the Host header is an ip address, localhost or the
+// name the server listens on. A page on a dns name that was rebound to this
+// machine still sends its own name.
+func (s *Server) allowedHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	return net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") ||
+		(s.host != "" && strings.EqualFold(host, s.host))
+}
+
+func (s *Server) hasToken(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = auth
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+// sameOrigin reports if the Origin header names the host the request went
+// to, "null" and other schemes do not
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host == host
+}
+
+//---------Syncing with the file-----------------------------
+
+// sync reloads the document when the file is newer than what we know,
+// it runs inside Do
+func (s *Server) sync() error {
+	if t := fileTime(s.doc.Filename); t.After(s.modTime) {
+		return s.opts.Reload()
+	}
+	return nil
+}
+
+func (s *Server) watch() {
+	for range time.Tick(time.Second) {
+		s.opts.Do(s.sync)
+	}
+}
+
+//---------Requests-----------------------------
+
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, a ...any) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, a...)}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	var nf *mdtodo.NotFoundError
+	if he, ok := err.(*httpError); ok {
+		code = he.code
+	} else if errors.As(err, &nf) {
+		code = http.StatusNotFound
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// read answers with what f returns, the document is up to date with the file
+func (s *Server) read(w http.ResponseWriter, f func() (any, error)) {
+	var result any
+	err := s.opts.Do(func() error {
+		if err := s.sync(); err != nil {
+			return err
+		}
+		var err error
+		result, err = f()
+		// clients find tasks by the ids they were given
+		s.doc.Projects.KeepIDs()
+		return err
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// change runs f and saves, f returns what to answer with. The result is
+// taken after the save, the sort policy may have moved things.
+func (s *Server) change(w http.ResponseWriter, code int, f func() (func() any, error)) {
+	var result any
+	err := s.opts.Do(func() error {
+		if err := s.sync(); err != nil {
+			return err
+		}
+		after, err := f()
+		if err != nil {
+			return err
+		}
+		s.doc.Projects.KeepIDs()
+		if err := s.opts.Save(); err != nil {
+			return err
+		}
+		if after != nil {
+			result = after()
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, code, result)
+}
+
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return badRequest("invalid JSON: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// newServer serves a todo file with the markdown from a temporary folder
+func newServer(t *testing.T, md string, opts Options) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "todo.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := mdtodo.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(d, opts)
+}
+
+// do sends a request to the handler the way the web ui does, body is JSON
+func do(s *Server, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Host = "127.0.0.1:8080"
+	if method != http.MethodGet {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		if k == "Host" {
+			r.Host = v
+		} else {
+			r.Header.Set(k, v)
+		}
+	}
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	return w
+}
+
+func decodeAs[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(w.Body).Decode(&v); err != nil {
+		t.Fatalf("answer %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func TestCRUD(t *testing.T) {
+	s := newServer(t, "# Todo\n\n## Main\n- [ ] first\n", Options{})
+
+	w := do(s, "GET", "/api/projects", "", nil)
+	list := decodeAs[mdtodo.JSONExport](t, w)
+	if w.Code != http.StatusOK || len(list.Projects) != 1 || list.Projects[0].Tasks[0].Name != "first" {
+		t.Fatalf("list gave %d %+v", w.Code, list)
+	}
+
+	w = do(s, "POST", "/api/projects", `{"name": "New"}`, nil)
+	p := decodeAs[mdtodo.JSONProject](t, w)
+	if w.Code != http.StatusCreated || p.Name != "New" || p.ID == "" {
+		t.Fatalf("adding a project gave %d %+v", w.Code, p)
+	}
+	w = do(s, "POST", "/api/projects/"+p.ID+"/tasks", `{"name": "write tests"}`, nil)
+	task := decodeAs[mdtodo.JSONTask](t, w)
+	if w.Code != http.StatusCreated || task.Name != "write tests" || task.Status != "open" {
+		t.Fatalf("adding a task gave %d %+v", w.Code, task)
+	}
+
+	w = do(s, "PATCH", "/api/tasks/"+task.ID, `{"name": "write more tests"}`, nil)
+	if got := decodeAs[mdtodo.JSONTask](t, w); w.Code != http.StatusOK || got.Name != "write more tests" || got.ID != task.ID {
+		t.Errorf("editing the task gave %d %+v", w.Code, got)
+	}
+	w = do(s, "POST", "/api/tasks/"+task.ID+"/toggle", "", nil)
+	if got := decodeAs[mdtodo.JSONTask](t, w); w.Code != http.StatusOK || got.Status != "done" {
+		t.Errorf("toggling the task gave %d %+v", w.Code, got)
+	}
+	w = do(s, "GET", "/api/tasks/"+task.ID, "", nil)
+	if got := decodeAs[mdtodo.JSONTask](t, w); w.Code != http.StatusOK || got.Status != "done" {
+		t.Errorf("getting the task gave %d %+v", w.Code, got)
+	}
+	w = do(s, "PATCH", "/api/projects/"+p.ID, `{"name": "Renamed"}`, nil)
+	if got := decodeAs[mdtodo.JSONProject](t, w); w.Code != http.StatusOK || got.Name != "Renamed" || len(got.Tasks) != 1 {
+		t.Errorf("editing the project gave %d %+v", w.Code, got)
+	}
+
+	saved, err := os.ReadFile(s.doc.Filename)
+	if err != nil || !strings.Contains(string(saved), "## Renamed") || !strings.Contains(string(saved), "- [x] write more tests") {
+		t.Errorf("the file is\n%s", saved)
+	}
+
+	if w = do(s, "DELETE", "/api/tasks/"+task.ID, "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("deleting the task gave %d", w.Code)
+	}
+	if w = do(s, "GET", "/api/tasks/"+task.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("a deleted task gave %d", w.Code)
+	}
+	if w = do(s, "DELETE", "/api/projects/"+p.ID, "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("deleting the project gave %d", w.Code)
+	}
+	if w = do(s, "GET", "/api/projects/"+p.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("a deleted project gave %d", w.Code)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := newServer(t, "# Todo\n\n## Main\n- [ ] first\n", Options{})
+	taskID := s.doc.Projects.Items[0].Tasks.Items[0].ID
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		header       map[string]string
+		want         int
+	}{
+		{"invalid JSON", "POST", "/api/projects", `{`, nil, http.StatusBadRequest},
+		{"no name", "POST", "/api/projects", `{"name": " "}`, nil, http.StatusBadRequest},
+		{"unknown status", "PATCH", "/api/tasks/" + taskID, `{"status": "sideways"}`, nil, http.StatusBadRequest},
+		{"unknown project to move to", "PATCH", "/api/tasks/" + taskID, `{"project": "nope"}`, nil, http.StatusNotFound},
+		{"unknown task", "GET", "/api/tasks/nope", "", nil, http.StatusNotFound},
+		{"unknown project", "POST", "/api/projects/nope/tasks", `{"name": "x"}`, nil, http.StatusNotFound},
+		{"a form", "POST", "/api/projects", `name=x`, map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, http.StatusUnsupportedMediaType},
+		{"another origin", "POST", "/api/projects", `{"name": "x"}`, map[string]string{"Origin": "http://evil.example"}, http.StatusForbidden},
+		{"the same origin", "POST", "/api/projects", `{"name": "x"}`, map[string]string{"Origin": "http://127.0.0.1:8080"}, http.StatusCreated},
+		{"a rebound name", "GET", "/api/projects", "", map[string]string{"Host": "rebind.example:8080"}, http.StatusForbidden},
+		{"the web ui on a rebound name", "GET", "/", "", map[string]string{"Host": "rebind.example"}, http.StatusForbidden},
+		{"localhost", "GET", "/api/projects", "", map[string]string{"Host": "localhost:8080"}, http.StatusOK},
+		{"ipv6 loopback", "GET", "/api/projects", "", map[string]string{"Host": "[::1]:8080"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := do(s, tt.method, tt.path, tt.body, tt.header); w.Code != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.name, w.Code, w.Body, tt.want)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	s := newServer(t, "# Todo\n\n## Main\n- [ ] first\n", Options{Token: "secret"})
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		want   int
+	}{
+		{"no token", "/api/projects", nil, http.StatusUnauthorized},
+		{"a wrong token", "/api/projects", map[string]string{"Authorization": "Bearer guess"}, http.StatusUnauthorized},
+		{"the token", "/api/projects", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"the token parameter", "/api/projects?token=secret", nil, http.StatusOK},
+		{"the web ui", "/", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := do(s, "GET", tt.path, "", tt.header); w.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	s := newServer(t, "# Todo\n", Options{})
+	l, err := s.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if s.opts.Token != "" || strings.Contains(s.URL(), "token") {
+		t.Errorf("loopback got a token, %s", s.URL())
+	}
+
+	s = newServer(t, "# Todo\n", Options{})
+	if l, err = s.Listen(":0"); err != nil {
+		t.Skip(err)
+	}
+	l.Close()
+	if s.opts.Token == "" || !strings.HasSuffix(s.URL(), "?token="+s.opts.Token) {
+		t.Errorf("listening on every address has no token, %s", s.URL())
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s := newServer(t, "# Todo\n\n## Main\n- [ ] first\n", Options{})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	// the stream says hello before the change is made, so the client is
+	// registered by then
+	if line := <-lines; line != ": connected" {
+		t.Fatalf("the stream started with %q", line)
+	}
+
+	taskID := s.doc.Projects.Items[0].Tasks.Items[0].ID
+	req, _ := http.NewRequest("POST", ts.URL+"/api/tasks/"+taskID+"/toggle", nil)
+	req.Header.Set("Content-Type", "application/json")
+	toggled, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toggled.Body.Close()
+
+	var kinds []string
+	timeout := time.After(5 * time.Second)
+	for len(kinds) < 2 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("the stream ended after %v", kinds)
+			}
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+			var c change
+			if err := json.Unmarshal([]byte(data), &c); err != nil {
+				t.Fatal(err)
+			}
+			if c.Kind == "task done" && c.Task != taskID {
+				t.Errorf("task done for %s, want %s", c.Task, taskID)
+			}
+			kinds = append(kinds, c.Kind)
+		case <-timeout:
+			t.Fatalf("got %v, want the task done and saved", kinds)
+		}
+	}
+	if kinds[0] != "task done" || kinds[1] != "saved" {
+		t.Errorf("got %v, want the task done and saved", kinds)
+	}
+}
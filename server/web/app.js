@@ -0,0 +1,145 @@
+// the web ui of mdtodo serve, it reads the same API as everybody else and
+// reloads on every event of the stream
+"use strict";
+
+const glyphs = { open: "☐", done: "☑", doing: "◐", cancelled: "⊘", deferred: "↷" };
+const hideDone = document.getElementById("hidedone");
+let data = { projects: [] };
+// the token of the address mdtodo serve printed, when it needs one
+const token = new URLSearchParams(location.search).get("token");
+
+hideDone.checked = localStorage.getItem("hidedone") !== "false";
+hideDone.onchange = () => {
+  localStorage.setItem("hidedone", hideDone.checked);
+  render();
+};
+
+async function api(method, path, body) {
+  const res = await fetch("/api" + path, {
+    method,
+    // the server takes changes only as JSON, even without a body
+    headers: {
+      ...(method === "GET" ? {} : { "Content-Type": "application/json" }),
+      ...(token ? { Authorization: "Bearer " + token } : {}),
+    },
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({ error: res.statusText }));
+    throw new Error(err.error);
+  }
+  return res.status === 204 ? null : res.json();
+}
+
+function showError(err) {
+  document.getElementById("error").textContent = err ? err.message : "";
+}
+
+async function load() {
+  try {
+    data = await api("GET", "/projects");
+    showError();
+  } catch (err) {
+    showError(err);
+  }
+  render();
+}
+
+function run(method, path, body) {
+  api(method, path, body).then(load, showError);
+}
+
+function closed(t) {
+  return t.status === "done" || t.status === "cancelled";
+}
+
+function el(tag, props, ...children) {
+  const e = document.createElement(tag);
+  Object.assign(e, props);
+  e.append(...children);
+  return e;
+}
+
+// move goes to the place of the next visible task above or below
+function move(project, task, dir) {
+  const visible = project.tasks.filter((t) => !hideDone.checked || !closed(t));
+  const other = visible[visible.indexOf(task) + dir];
+  if (other) {
+    run("PATCH", "/tasks/" + task.id, { index: project.tasks.indexOf(other) });
+  }
+}
+
+function taskItem(project, t) {
+  const li = el("li", { className: t.status });
+  li.append(
+    el("button", { className: "state", title: "toggle", onclick: () => run("POST", "/tasks/" + t.id + "/toggle") }, glyphs[t.status] || "[" + t.status + "]"),
+    el("span", { className: "name" }, (t.tag ? t.tag + " " : "") + t.name.replace(/(📅\s*|due:)\d{4}-\d{2}-\d{2}/, "").trim()),
+  );
+  if (t.due) {
+    const overdue = !closed(t) && t.due < new Date().toISOString().slice(0, 10);
+    li.append(el("span", { className: "due" + (overdue ? " overdue" : "") }, "📅 " + t.due));
+  }
+  li.append(
+    el("button", { title: "move up", onclick: () => move(project, t, -1) }, "▲"),
+    el("button", { title: "move down", onclick: () => move(project, t, +1) }, "▼"),
+  );
+  return li;
+}
+
+function render() {
+  const main = document.getElementById("projects");
+  main.replaceChildren();
+  let done = 0, total = 0;
+  for (const p of data.projects) {
+    const counted = p.tasks.filter((t) => t.status !== "cancelled");
+    const pdone = counted.filter((t) => t.status === "done").length;
+    done += pdone;
+    total += counted.length;
+
+    const ul = el("ul");
+    for (const t of p.tasks) {
+      if (!hideDone.checked || !closed(t)) {
+        ul.append(taskItem(p, t));
+      }
+    }
+    const input = el("input", { name: "name", placeholder: "Add a task", autocomplete: "off" });
+    const form = el("form", {
+      onsubmit: (e) => {
+        e.preventDefault();
+        if (input.value.trim()) {
+          run("POST", "/projects/" + p.id + "/tasks", { name: input.value });
+        }
+      },
+    }, input);
+    main.append(el("section", {}, el("h2", {}, p.name, el("span", { className: "muted" }, pdone + "/" + counted.length)), ul, form));
+  }
+  document.getElementById("progress").textContent = done + "/" + total + " done";
+}
+
+document.getElementById("addproject").onsubmit = (e) => {
+  e.preventDefault();
+  const input = e.target.elements.name;
+  if (input.value.trim()) {
+    run("POST", "/projects", { name: input.value });
+    input.value = "";
+  }
+};
+
+function listen() {
+  const live = document.getElementById("live");
+  const events = new EventSource("/api/events" + (token ? "?token=" + encodeURIComponent(token) : ""));
+  let timer;
+  events.onopen = () => {
+    live.classList.add("on");
+    load();
+  };
+  events.onerror = () => live.classList.remove("on");
+  // a change often comes with a save, one reload is enough
+  events.onmessage = () => {
+    clearTimeout(timer);
+    timer = setTimeout(load, 100);
+  };
+}
+
+load();
+listen();
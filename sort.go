@@ -0,0 +1,101 @@
+package mdtodo
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// due dates follow the obsidian tasks style `📅 2025-03-11`, `due:2025-03-11` also works
+var dueRegex = regexp.MustCompile(`(?:📅\s*|due:)(\d{4}-\d{2}-\d{2})`)
+
+// Due returns the due date found in the task name
+func (t Task) Due() (time.Time, bool) {
+	m := dueRegex.FindStringSubmatch(t.Name)
+	if m == nil {
+		return time.Time{}, false
+	}
+	d, err := time.ParseInLocation(DateLayout, m[1], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// DueBy reports if the task is due on the day of now or earlier
+func (t Task) DueBy(now time.Time) bool {
+	d, ok := t.Due()
+	if !ok {
+		return false
+	}
+	y, m, day := now.Date()
+	return d.Before(time.Date(y, m, day+1, 0, 0, 0, 0, now.Location()))
+}
+
+// priorities follow the obsidian tasks emojis, the 🔥 tag counts as high
+var priorities = []struct {
+	emoji string
+	level int
+}{
+	{"🔺", 0},
+	{"⏫", 1},
+	{"🔥", 1},
+	{"🔼", 2},
+	{"🔽", 4},
+	{"⏬", 5},
+}
+
+const normalPriority = 3
+
+// Priority returns the level of the task, lower is more important
+func (t Task) Priority() int {
+	for _, p := range priorities {
+		if t.Tag == p.emoji || strings.Contains(t.Name, p.emoji) {
+			return p.level
+		}
+	}
+	return normalPriority
+}
+
+// TaskLess is a "should a come before b" function used to order tasks
+type TaskLess func(a, b *Task) bool
+
+var SortKeys = map[string]TaskLess{
+	"name": func(a, b *Task) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	},
+	"done": func(a, b *Task) bool {
+		return !a.Done() && b.Done()
+	},
+	"priority": func(a, b *Task) bool {
+		return a.Priority() < b.Priority()
+	},
+	// tasks without a due date go last
+	"due": func(a, b *Task) bool {
+		ad, aok := a.Due()
+		bd, bok := b.Due()
+		if aok != bok {
+			return aok
+		}
+		return ad.Before(bd)
+	},
+}
+
+func SortKeyNames() []string {
+	names := make([]string, 0, len(SortKeys))
+	for k := range SortKeys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SortTasks stable sorts the tasks, keeping the selected task selected
+func (b *Project) SortTasks(less TaskLess) {
+	sort.SliceStable(b.Tasks.Items, func(i, j int) bool {
+		return less(b.Tasks.Items[i], b.Tasks.Items[j])
+	})
+}
@@ -0,0 +1,97 @@
+package mdtodo
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Status is what is written between the brackets of a task checkbox. States
+// mdtodo does not know are kept as they are, so files shared with other
+// tools survive a save.
+type Status string
+
+const (
+	StatusOpen      Status = " "
+	StatusDone      Status = "x"
+	StatusDoing     Status = "/"
+	StatusCancelled Status = "-"
+	StatusDeferred  Status = ">"
+)
+
+func (s Status) IsDone() bool {
+	return s == StatusDone || s == "X"
+}
+
+// Closed states need no more work, they count as done for hiding
+func (s Status) Closed() bool {
+	return s.IsDone() || s == StatusCancelled
+}
+
+var statusNames = []struct {
+	name   string
+	status Status
+}{
+	{"open", StatusOpen},
+	{"done", StatusDone},
+	{"doing", StatusDoing},
+	{"cancelled", StatusCancelled},
+	{"deferred", StatusDeferred},
+}
+
+// Name is the word used for the state on the command line and in exports,
+// states without a name give their character
+func (s Status) Name() string {
+	if s.IsDone() {
+		return "done"
+	}
+	for _, n := range statusNames {
+		if n.status == s {
+			return n.name
+		}
+	}
+	return string(s)
+}
+
+// StatusNamed returns the state with that name, any single character other
+// than ] is a state as is
+func StatusNamed(name string) (Status, bool) {
+	for _, n := range statusNames {
+		if n.name == name {
+			return n.status, true
+		}
+	}
+	if utf8.RuneCountInString(name) != 1 || name == "]" {
+		return StatusOpen, false
+	}
+	return Status(name), true
+}
+
+// StatusNames lists the named states
+func StatusNames() []string {
+	var names []string
+	for _, n := range statusNames {
+		names = append(names, n.name)
+	}
+	return names
+}
+
+// ParseStatus reads the checkbox at the start of a task line like
+// "- [x] name", it returns the status and the rest of the line.
+func ParseStatus(line string) (Status, string, bool) {
+	rest, ok := strings.CutPrefix(line, "- [")
+	if !ok {
+		return StatusOpen, line, false
+	}
+	for i, r := range rest {
+		if r == ']' {
+			if i == 0 {
+				return StatusOpen, rest[1:], true
+			}
+			return Status(rest[:i]), rest[i+1:], true
+		}
+		if i > 0 {
+			break
+		}
+	}
+	return StatusOpen, line, false
+}
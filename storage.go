@@ -0,0 +1,68 @@
+package mdtodo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage reads and writes projects in one file format
+type Storage interface {
+	Load(filename string) (Projects, error)
+	Save(filename string, ps Projects) error
+}
+
+// Markdown is the mdtodo format, `## Project` and `- [ ] task`
+type Markdown struct{}
+
+func (Markdown) Load(filename string) (Projects, error) {
+	return ReadFromFile(filename)
+}
+
+func (Markdown) Save(filename string, ps Projects) error {
+	return ps.SaveToFile(filename)
+}
+
+// TodoTxt keeps the projects in the todo.txt format, see ReadTodoTxt. It
+// refuses to save notes unless Lossy is set, they would be gone.
+type TodoTxt struct {
+	Lossy bool
+}
+
+func (TodoTxt) Load(filename string) (Projects, error) {
+	SendHeartbeat(filename, "")
+	file, err := os.Open(filename)
+	if err != nil {
+		return NewProjects(), err
+	}
+	defer file.Close()
+
+	return ReadTodoTxt(file)
+}
+
+func (s TodoTxt) Save(filename string, ps Projects) error {
+	if err := TodoTxtNotes(ps); err != nil && !s.Lossy {
+		return err
+	}
+	SendHeartbeat(filename, "")
+	ps.AssignIDs()
+	var sb strings.Builder
+	if err := WriteTodoTxt(&sb, ps); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(sb.String()), 0644)
+}
+
+// StorageFor picks the format from the file name, .txt files are todo.txt
+// and everything else markdown
+func StorageFor(filename string) Storage {
+	if strings.EqualFold(filepath.Ext(filename), ".txt") {
+		return TodoTxt{}
+	}
+	return Markdown{}
+}
+
+// LoadFile reads a todo file in the format StorageFor picks
+func LoadFile(filename string) (Projects, error) {
+	return StorageFor(filename).Load(filename)
+}
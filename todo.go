@@ -0,0 +1,366 @@
+package mdtodo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ---------- Misc function-------------------
+func updateSelected[T any](items []*T, selected *T, direction int) *T {
+	if len(items) == 0 {
+		return nil
+	}
+
+	selectedFound := false
+	for i, item := range items {
+		if selected == item {
+			selectedFound = true
+			if direction == 1 {
+				if i+1 < len(items) {
+					return items[i+1]
+				} else {
+					return selected
+					//return items[0]
+				}
+			} else if direction == -1 {
+				if i-1 >= 0 {
+					return items[i-1]
+				} else {
+					return selected
+					//return items[len(items)-1]
+				}
+			}
+			break
+		}
+	}
+	if !selectedFound {
+		return items[0]
+	}
+	return selected
+}
+
+//---------- Collection-------------------
+
+type Collection[T any] struct {
+	Items    []*T
+	Selected *T
+}
+
+func (b *Collection[T]) Add(item *T) *T {
+	b.Items = append(b.Items, item)
+	b.Selected = item
+	return item
+}
+
+func (b *Collection[T]) RemoveSelected() {
+	if b.Selected != nil {
+		next := b.findNext()
+		b.Remove(b.Selected)
+		b.Selected = next
+	}
+}
+
+func (b *Collection[T]) Remove(item *T) {
+	for i, t := range b.Items {
+		if t == item {
+			b.Select(-1)
+			b.Items = append(b.Items[:i], b.Items[i+1:]...)
+			break
+		}
+	}
+}
+
+// RemoveAll removes the given items, if the selected item is one of them the
+// selection moves to the next remaining item.
+func (b *Collection[T]) RemoveAll(remove map[*T]bool) {
+	index, _ := b.FindIndex(b.Selected)
+
+	var kept []*T
+	var next, prev *T
+	for i, item := range b.Items {
+		if remove[item] {
+			continue
+		}
+		kept = append(kept, item)
+		if i < index {
+			prev = item
+		} else if i > index && next == nil {
+			next = item
+		}
+	}
+
+	if remove[b.Selected] {
+		b.Selected = next
+		if next == nil {
+			b.Selected = prev
+		}
+	}
+	b.Items = kept
+}
+
+func (b *Collection[T]) SelectFirst() *T {
+	if len(b.Items) > 0 {
+		b.Selected = b.Items[0]
+	}
+	return b.Selected
+}
+
+func (b *Collection[T]) SelectLast() *T {
+	if len(b.Items) > 0 {
+		b.Selected = b.Items[len(b.Items)-1]
+	}
+	return b.Selected
+}
+
+func (b *Collection[T]) findNext() *T {
+	index, found := b.FindIndex(b.Selected)
+
+	if !found {
+		return nil
+	}
+
+	newIndex := index + 1
+	if newIndex < 0 || newIndex >= len(b.Items) {
+		return b.Items[len(b.Items)-1]
+	}
+
+	return b.Items[newIndex]
+
+}
+
+func (b *Collection[T]) Select(dir int) *T {
+	b.Selected = updateSelected(b.Items, b.Selected, dir)
+	return b.Selected
+}
+
+// FindIndex finds the index of a given item in the items slice.
+func (c *Collection[T]) FindIndex(item *T) (int, bool) {
+	if item == nil {
+		return -1, false // No item provided
+	}
+
+	for i, v := range c.Items {
+		if v == item {
+			return i, true
+		}
+	}
+	return -1, false // Item not found
+}
+
+func (c *Collection[T]) MoveSelected(dir int) *T {
+	index, found := c.FindIndex(c.Selected)
+	if !found {
+		return nil
+	}
+
+	newIndex := index + dir
+	if newIndex < 0 || newIndex >= len(c.Items) {
+		return c.Items[index]
+	}
+
+	// Swap the selected item with the new position
+	c.Items[index], c.Items[newIndex] = c.Items[newIndex], c.Items[index]
+	return c.Items[index]
+}
+
+// ---------- Task ann Projects-------------------
+type Task struct {
+	Status Status
+	Name   string
+	Tag    string
+	Notes  string
+	ID     string // see AssignIDs
+	KeepID bool   // the id is written to the file, see KeepIDs
+}
+
+func (t Task) Done() bool {
+	return t.Status.Closed()
+}
+
+func (t Task) String() string {
+	var sb strings.Builder
+	sb.WriteString("[" + string(t.Status) + "] ")
+	if t.Tag != "" {
+		sb.WriteString(fmt.Sprintf("%s ", t.Tag))
+	}
+	sb.WriteString(t.Name)
+	if t.KeepID && t.ID != "" {
+		sb.WriteString(" " + IDMark + " " + t.ID)
+	}
+	if t.Notes != "" {
+		sb.WriteString(fmt.Sprintf("\n%v", t.Notes))
+	}
+	return sb.String()
+}
+
+type Project struct {
+	Name   string
+	Tasks  Collection[Task]
+	Notes  string
+	Folded bool
+	File   string // in global mode the todo file the project is saved to
+	ID     string
+	KeepID bool
+}
+
+func (p Project) String() string {
+	result := fmt.Sprintf("## %s\n", p.Name)
+	if p.KeepID && p.ID != "" {
+		result = fmt.Sprintf("## %s <!-- id: %s -->\n", p.Name, p.ID)
+	}
+	if p.Notes != "" {
+		result += (fmt.Sprintf("%v\n", p.Notes))
+	}
+
+	for _, task := range p.Tasks.Items {
+		result += fmt.Sprintf("- %s\n", task)
+	}
+	return result
+}
+
+func (b *Project) Add(name string) *Task {
+	item := &Task{
+		Status: StatusOpen,
+		Name:   strings.TrimSuffix(name, "\n"),
+		Notes:  "",
+	}
+
+	b.Tasks.Add(item)
+
+	return item
+}
+
+func (b *Project) Select(dir int, skipdone bool) *Task {
+	if b.Folded {
+		return b.Tasks.Selected
+	}
+	var t *Task
+	for {
+		p := b.Tasks.Selected //check if it did not move
+		t = b.Tasks.Select(dir)
+		if t == nil || !skipdone || !t.Done() || p == t {
+			break
+		}
+	}
+	return t
+}
+func (b *Project) MoveSelected(dir int, skipdone bool) *Task {
+	if b.Folded {
+		return b.Tasks.Selected
+	}
+	var t *Task
+	for {
+		p := b.Tasks.Selected //check if it did not move
+		t = b.Tasks.MoveSelected(dir)
+		if t == nil || !skipdone || !t.Done() || p == t {
+			break
+		}
+	}
+	return t
+}
+
+// Progress counts the done tasks, cancelled ones do not count at all
+func (b *Project) Progress() (int, int) {
+	done, total := 0, 0
+	for _, t := range b.Tasks.Items {
+		if t.Status == StatusCancelled {
+			continue
+		}
+		if t.Status.IsDone() {
+			done++
+		}
+		total++
+	}
+	return done, total
+}
+
+func NewProject(name string) *Project {
+	tasks := &Project{
+		Tasks: Collection[Task]{Items: make([]*Task, 0)},
+		Name:  strings.TrimSuffix(name, "\n"),
+	}
+	return tasks
+}
+
+type Projects struct {
+	Collection[Project]
+}
+
+func (ps Projects) String() string {
+	result := "# Todo\n\n"
+	for _, project := range ps.Items {
+		result += fmt.Sprintf("%s\n", project)
+	}
+	return result
+}
+
+func (ps Projects) SaveToFile(filename string) error {
+	SendHeartbeat(filename, "")
+	ps.AssignIDs()
+	content := ps.String()
+	return os.WriteFile(filename, []byte(content), 0644) // Write to file with appropriate permissions
+}
+
+func NewProjects() Projects {
+	return Projects{
+		Collection: Collection[Project]{Items: make([]*Project, 0)},
+	}
+}
+
+func ReadFromFile(filename string) (Projects, error) {
+	SendHeartbeat(filename, "")
+	file, err := os.Open(filename)
+	if err != nil {
+		return NewProjects(), err
+	}
+	defer file.Close()
+
+	return ReadFrom(file)
+}
+
+// ReadFrom parses the markdown todo format
+func ReadFrom(r io.Reader) (Projects, error) {
+	var projects Projects
+	var currentProject *Project
+	var currentTask *Task
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "## ") { // Detect project name
+			projectName, id := splitProjectID(strings.TrimPrefix(line, "## "))
+			currentProject = &Project{Name: projectName, Notes: "", ID: id, KeepID: id != ""}
+			projects.Add(currentProject)
+			currentTask = nil
+		} else if status, rest, ok := ParseStatus(line); ok { // Detect task, a line like "- [ab]" is note text
+			if currentProject != nil {
+				emoji, taskName := ExtractEmoji(strings.TrimSpace(rest))
+				taskName, id := splitID(taskName)
+				currentTask = &Task{Status: status, Name: taskName, Tag: emoji, Notes: "", ID: id, KeepID: id != ""}
+				currentProject.Tasks.Add(currentTask)
+			}
+		} else if currentTask != nil {
+			if currentTask.Notes != "" {
+				currentTask.Notes += "\n"
+			}
+			currentTask.Notes += strings.TrimSpace(line)
+		} else if currentProject != nil {
+			if currentProject.Notes != "" {
+				currentProject.Notes += "\n"
+			}
+			currentProject.Notes += strings.TrimSpace(line)
+
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return NewProjects(), err
+	}
+
+	projects.AssignIDs()
+	return projects, nil
+}
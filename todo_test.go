@@ -0,0 +1,73 @@
+package mdtodo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadFromMalformedCheckbox(t *testing.T) {
+	for _, line := range []string{"- [", "- [ab] foo", "- [x"} {
+		ps, err := ReadFrom(strings.NewReader("# Todo\n\n## Main\n- [ ] task\n" + line + "\n"))
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if len(ps.Items) != 1 || len(ps.Items[0].Tasks.Items) != 1 {
+			t.Fatalf("%q: want one project with one task, got %s", line, ps)
+		}
+		task := ps.Items[0].Tasks.Items[0]
+		if task.Name != "task" || task.Notes != line {
+			t.Errorf("%q: want it as the notes of the task, got name %q notes %q", line, task.Name, task.Notes)
+		}
+		if !strings.Contains(ps.String(), line) {
+			t.Errorf("%q: the line is not written back as it was:\n%s", line, ps)
+		}
+	}
+}
+
+func TestReadFromStatuses(t *testing.T) {
+	ps, err := ReadFrom(strings.NewReader("# Todo\n\n## Main\nproject note\n- [ ] open\n- [x] done\n  a note\n- [/] doing\n- [?] unknown\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := ps.Items[0]
+	if p.Name != "Main" || p.Notes != "project note" {
+		t.Errorf("project %q notes %q", p.Name, p.Notes)
+	}
+	want := []struct {
+		name   string
+		status Status
+		notes  string
+	}{
+		{"open", StatusOpen, ""},
+		{"done", StatusDone, "a note"},
+		{"doing", StatusDoing, ""},
+		{"unknown", Status("?"), ""},
+	}
+	if len(p.Tasks.Items) != len(want) {
+		t.Fatalf("got %d tasks", len(p.Tasks.Items))
+	}
+	for i, w := range want {
+		task := p.Tasks.Items[i]
+		if task.Name != w.name || task.Status != w.status || task.Notes != w.notes {
+			t.Errorf("task %d: got %q %q %q, want %q %q %q", i, task.Name, task.Status, task.Notes, w.name, w.status, w.notes)
+		}
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	ps, err := ReadFrom(strings.NewReader("# Todo\n\n## Main\nnotes\n- [ ] 🔥 hot 📅 2026-01-02\n- [x] done ✅ 2026-01-01\n- [-] dropped\n\n## Later\n- [>] some day\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := ps.String()
+	again, err := ReadFrom(strings.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != first {
+		t.Errorf("not stable:\n%s\n---\n%s", first, again)
+	}
+	if task := ps.Items[0].Tasks.Items[0]; task.Tag != "🔥" || task.Name != "hot 📅 2026-01-02" {
+		t.Errorf("tag %q name %q", task.Tag, task.Name)
+	}
+}
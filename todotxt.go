@@ -0,0 +1,240 @@
+package mdtodo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todo.txt has no notes and no empty projects, they are lost on the way.
+// Projects become +Project, with _ for spaces, the tag and #tags become
+// @contexts and the priority emojis the (A) to (E) letters. Completed and
+// created dates move between the obsidian style marks and the dates at the
+// start of the line, states other than open and done are kept in a
+// status:doing field, and a kept id of a task goes in an id: field.
+
+const (
+	CreatedMark = "➕"
+	inboxName   = "Inbox"
+)
+
+var (
+	createdRegex    = regexp.MustCompile(`\s*` + CreatedMark + `\s*(\d{4}-\d{2}-\d{2})`)
+	hashTagRegex    = regexp.MustCompile(`(^|\s)#([^\s#]+)`)
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	// the priority emojis as letters, like todo.txt and org-mode use them
+	priorityLetters = []struct {
+		letter string
+		emoji  string
+	}{
+		{"A", "🔺"},
+		{"B", "⏫"},
+		{"C", "🔼"},
+		{"D", "🔽"},
+		{"E", "⏬"},
+	}
+)
+
+// priorityLetter takes the first priority emoji out of the name and returns
+// its letter, or "" when there is none
+func priorityLetter(name string) (string, string) {
+	for _, p := range priorityLetters {
+		if strings.Contains(name, p.emoji) {
+			return p.letter, strings.Replace(name, p.emoji, "", 1)
+		}
+	}
+	return "", name
+}
+
+// priorityEmoji is the emoji of a letter, letters after E are the lowest
+func priorityEmoji(letter string) string {
+	for _, p := range priorityLetters {
+		if p.letter == letter {
+			return p.emoji
+		}
+	}
+	return priorityLetters[len(priorityLetters)-1].emoji
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse(DateLayout, s)
+	return err == nil
+}
+
+// ReadTodoTxt parses a todo.txt file, tasks without a +project go to Inbox
+func ReadTodoTxt(r io.Reader) (Projects, error) {
+	ps := NewProjects()
+	projects := map[string]*Project{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		name, t := parseTodoTxt(line)
+		p := projects[name]
+		if p == nil {
+			p = ps.Add(NewProject(name))
+			projects[name] = p
+		}
+		p.Tasks.Add(t)
+	}
+	if err := scanner.Err(); err != nil {
+		return NewProjects(), err
+	}
+	ps.AssignIDs()
+	return ps, nil
+}
+
+func parseTodoTxt(line string) (string, *Task) {
+	t := &Task{Status: StatusOpen}
+	words := strings.Fields(line)
+	var completed, created, priority, project string
+
+	if words[0] == "x" {
+		t.Status = StatusDone
+		words = words[1:]
+		if len(words) > 0 && isDate(words[0]) {
+			completed, words = words[0], words[1:]
+			if len(words) > 0 && isDate(words[0]) {
+				created, words = words[0], words[1:]
+			}
+		}
+	} else {
+		if m := todoTxtPriority.FindStringSubmatch(words[0]); m != nil {
+			priority, words = m[1], words[1:]
+		}
+		if len(words) > 0 && isDate(words[0]) {
+			created, words = words[0], words[1:]
+		}
+	}
+
+	var rest []string
+	for _, w := range words {
+		switch {
+		case project == "" && len(w) > 1 && w[0] == '+':
+			project = strings.ReplaceAll(w[1:], "_", " ")
+		case len(w) > 1 && w[0] == '@':
+			if tag, more := ExtractEmoji(w[1:]); tag != "" && more == "" && t.Tag == "" {
+				t.Tag = tag
+			} else {
+				rest = append(rest, "#"+w[1:])
+			}
+		case strings.HasPrefix(w, "status:"):
+			if s, ok := StatusNamed(strings.TrimPrefix(w, "status:")); ok {
+				t.Status = s
+			} else {
+				rest = append(rest, w)
+			}
+		case strings.HasPrefix(w, "pri:") && len(w) == 5:
+			priority = w[4:]
+		case strings.HasPrefix(w, "id:") && len(w) > 3 && t.ID == "":
+			t.ID, t.KeepID = w[3:], true
+		default:
+			rest = append(rest, w)
+		}
+	}
+
+	if priority != "" {
+		rest = append(rest, priorityEmoji(priority))
+	}
+	if created != "" {
+		rest = append(rest, CreatedMark, created)
+	}
+	if completed != "" {
+		rest = append(rest, DoneMark, completed)
+	}
+	t.Name = strings.Join(rest, " ")
+	if project == "" {
+		project = inboxName
+	}
+	return project, t
+}
+
+// TodoTxtNotes reports the first notes todo.txt cannot keep
+func TodoTxtNotes(ps Projects) error {
+	for _, p := range ps.Items {
+		if p.Notes != "" {
+			return fmt.Errorf("todo.txt has no notes, the ones of project %q would be lost", p.Name)
+		}
+		for _, t := range p.Tasks.Items {
+			if t.Notes != "" {
+				return fmt.Errorf("todo.txt has no notes, the ones of %q would be lost", t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// EmptyProjects lists the projects without tasks, todo.txt drops them
+func (ps Projects) EmptyProjects() []string {
+	var names []string
+	for _, p := range ps.Items {
+		if len(p.Tasks.Items) == 0 {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// WriteTodoTxt writes a line for every task
+func WriteTodoTxt(w io.Writer, ps Projects) error {
+	for _, p := range ps.Items {
+		for _, t := range p.Tasks.Items {
+			if _, err := fmt.Fprintln(w, todoTxtLine(p, t)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func todoTxtLine(p *Project, t *Task) string {
+	name := t.Name
+	var completed, created, priority string
+	if m := DoneRegex.FindStringSubmatch(name); m != nil && t.Status.Closed() {
+		completed = m[1]
+		name = DoneRegex.ReplaceAllString(name, "")
+	}
+	// todo.txt only has a created date on done tasks that have a completed one
+	if m := createdRegex.FindStringSubmatch(name); m != nil && (completed != "" || !t.Status.Closed()) {
+		created = m[1]
+		name = createdRegex.ReplaceAllString(name, "")
+	}
+	priority, name = priorityLetter(name)
+	name = dueRegex.ReplaceAllString(name, "due:$1")
+	name = hashTagRegex.ReplaceAllString(name, "$1@$2")
+
+	var parts []string
+	if t.Status.Closed() {
+		parts = append(parts, "x")
+		if completed != "" {
+			parts = append(parts, completed)
+		}
+	} else if priority != "" {
+		parts = append(parts, "("+priority+")")
+	}
+	if created != "" {
+		parts = append(parts, created)
+	}
+	parts = append(parts, strings.Fields(name)...)
+	if p.Name != "" && p.Name != inboxName {
+		parts = append(parts, "+"+strings.ReplaceAll(p.Name, " ", "_"))
+	}
+	if t.Tag != "" {
+		parts = append(parts, "@"+t.Tag)
+	}
+	if t.Status.Closed() && priority != "" {
+		parts = append(parts, "pri:"+priority)
+	}
+	if t.Status != StatusOpen && !t.Status.IsDone() {
+		parts = append(parts, "status:"+t.Status.Name())
+	}
+	if t.KeepID && t.ID != "" {
+		parts = append(parts, "id:"+t.ID)
+	}
+	return strings.Join(parts, " ")
+}
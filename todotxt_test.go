@@ -0,0 +1,62 @@
+package mdtodo
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	ps := readProjects(t, "# Todo\n\n## Main\n- [ ] 🔥 hot ⏫ 📅 2026-01-02\n- [x] done ✅ 2026-01-01\n\n## Later\n- [ ] some day\n")
+	ps.KeepIDs()
+	var buf bytes.Buffer
+	if err := WriteTodoTxt(&buf, ps); err != nil {
+		t.Fatal(err)
+	}
+	first := buf.String()
+	back, err := ReadTodoTxt(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err := WriteTodoTxt(&again, back); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != first {
+		t.Errorf("not stable:\n%s\n---\n%s", first, again.String())
+	}
+	if len(back.Items) != 2 || back.Items[1].Name != "Later" || !back.Items[0].Tasks.Items[1].Done() {
+		t.Errorf("read back\n%s\nfrom\n%s", back, first)
+	}
+	for i, task := range back.Items[0].Tasks.Items {
+		if want := ps.Items[0].Tasks.Items[i].ID; task.ID != want {
+			t.Errorf("task %d has id %q, want %q", i, task.ID, want)
+		}
+	}
+}
+
+func TestTodoTxtNotes(t *testing.T) {
+	tests := []struct {
+		md   string
+		lost bool
+	}{
+		{"## Main\n- [ ] plain\n", false},
+		{"## Main\n- [ ] task\n  a note\n", true},
+		{"## Main\nproject notes\n- [ ] task\n", true},
+		{"## Main\n- [ ] task\n\n## Empty\n", false},
+	}
+	for _, tt := range tests {
+		ps := readProjects(t, tt.md)
+		if err := TodoTxtNotes(ps); (err != nil) != tt.lost {
+			t.Errorf("%q: got %v, want lost %v", tt.md, err, tt.lost)
+		}
+		if err := (TodoTxt{}).Save(t.TempDir()+"/todo.txt", ps); (err != nil) != tt.lost {
+			t.Errorf("%q: saving gave %v", tt.md, err)
+		}
+		if err := (TodoTxt{Lossy: true}).Save(t.TempDir()+"/todo.txt", ps); err != nil {
+			t.Errorf("%q: a lossy save gave %v", tt.md, err)
+		}
+	}
+	if got := readProjects(t, "## A\n- [ ] a\n\n## B\n\n## C\n").EmptyProjects(); len(got) != 2 || got[0] != "B" || got[1] != "C" {
+		t.Errorf("empty projects %v, want B and C", got)
+	}
+}
@@ -0,0 +1,296 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+// archiveSection is the project archived tasks go to when they stay in the
+// todo file
+const archiveSection = "Archive"
+
+// in the archive section a task remembers its project in a dataview style
+// field, `[project:: test 2]`
+var projectField = regexp.MustCompile(`\s*\[project:: ([^\]]*)\]`)
+
+// archivePath is the sibling archive file, todo.md keeps todo.archive.md
+func archivePath(todo string) string {
+	ext := filepath.Ext(todo)
+	return strings.TrimSuffix(todo, ext) + ".archive" + ext
+}
+
+func archiveInFile() bool {
+	return settings.Archive != "section"
+}
+
+// projectNamed finds the project with exactly that name, or adds it at the
+// end without selecting it
+func projectNamed(ps *Projects, name string) *Project {
+	for _, p := range ps.Items {
+		if p.Name == name {
+			return p
+		}
+	}
+	p := mdtodo.NewProject(name)
+	ps.Items = append(ps.Items, p)
+	return p
+}
+
+// archivable returns the closed tasks, with days > 0 only the ones completed
+// at least that many days ago
+func archivable(ps Projects, days int, now time.Time) []taskRef {
+	cutoff := now.AddDate(0, 0, -days)
+	var refs []taskRef
+	for _, p := range ps.Items {
+		if p.Name == archiveSection {
+			continue
+		}
+		for _, t := range p.Tasks.Items {
+			if !t.Done() {
+				continue
+			}
+			if days > 0 {
+				if when, ok := t.Completed(); !ok || when.After(cutoff) {
+					continue
+				}
+			}
+			refs = append(refs, taskRef{p, t})
+		}
+	}
+	return refs
+}
+
+// keepBefore remembers a file other than the open one before it is first
+// written in this change, recordUndo hands it to the undo step
+func keepBefore(path string) {
+	if doc.archiveBefore == nil {
+		doc.archiveBefore, doc.archiveAfter = map[string]string{}, map[string]string{}
+	}
+	if _, ok := doc.archiveBefore[path]; !ok {
+		before, _ := os.ReadFile(path)
+		doc.archiveBefore[path] = string(before)
+	}
+}
+
+// keepAfter remembers the file as written, redo puts it back
+func keepAfter(path string) {
+	after, _ := os.ReadFile(path)
+	doc.archiveAfter[path] = string(after)
+}
+
+// archiveTasks moves the tasks out of their projects, into the archive file
+// under a project of the same name or into the archive section
+func archiveTasks(refs []taskRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	if !archiveInFile() {
+		removeTasks(refs)
+		section := projectNamed(&doc.Projects, archiveSection)
+		for _, ref := range refs {
+			ref.task.Name += " [project:: " + ref.project.Name + "]"
+			section.Tasks.Items = append(section.Tasks.Items, ref.task)
+		}
+		return nil
+	}
+
+	// in global mode every file has its own archive
+	byFile := map[string][]taskRef{}
+	for _, ref := range refs {
+		path := archivePath(projectFile(ref.project))
+		byFile[path] = append(byFile[path], ref)
+	}
+	for path, refs := range byFile {
+		keepBefore(path)
+		archive, err := mdtodo.LoadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, ref := range refs {
+			p := projectNamed(&archive, ref.project.Name)
+			p.Tasks.Items = append(p.Tasks.Items, ref.task)
+		}
+		if err := mdtodo.StorageFor(path).Save(path, archive); err != nil {
+			return err
+		}
+		keepAfter(path)
+		removeTasks(refs)
+	}
+	return nil
+}
+
+// restoreTasks puts archived tasks back into the project they came from
+func restoreTasks(refs []taskRef) error {
+	var fromSection, fromFile []taskRef
+	for _, ref := range refs {
+		switch {
+		case ref.project.Name == archiveSection:
+			fromSection = append(fromSection, ref)
+		case doc.archiveOf != "":
+			fromFile = append(fromFile, ref)
+		}
+	}
+	if len(fromSection) == 0 && len(fromFile) == 0 {
+		return fmt.Errorf("nothing to restore, :archived shows the archive")
+	}
+
+	removeTasks(fromSection)
+	for _, ref := range fromSection {
+		name := archiveSection
+		if m := projectField.FindStringSubmatch(ref.task.Name); m != nil {
+			name = m[1]
+		}
+		ref.task.Name = projectField.ReplaceAllString(ref.task.Name, "")
+		p := projectNamed(&doc.Projects, name)
+		p.Tasks.Items = append(p.Tasks.Items, ref.task)
+	}
+
+	if len(fromFile) > 0 {
+		keepBefore(doc.archiveOf)
+		todo, err := mdtodo.LoadFile(doc.archiveOf)
+		if err != nil {
+			return err
+		}
+		for _, ref := range fromFile {
+			p := projectNamed(&todo, ref.project.Name)
+			p.Tasks.Items = append(p.Tasks.Items, ref.task)
+		}
+		if err := mdtodo.StorageFor(doc.archiveOf).Save(doc.archiveOf, todo); err != nil {
+			return err
+		}
+		keepAfter(doc.archiveOf)
+		removeTasks(fromFile)
+	}
+	return nil
+}
+
+// autoArchive archives the tasks completed more than ArchiveAfter days ago,
+// it runs when a todo file is opened and undo starts after it
+func autoArchive() {
+	if settings.ArchiveAfter <= 0 || doc.archiveOf != "" {
+		return
+	}
+	refs := archivable(doc.Projects, settings.ArchiveAfter, time.Now())
+	if len(refs) == 0 {
+		return
+	}
+	if err := archiveTasks(refs); err != nil {
+		statusMsg = err.Error()
+		return
+	}
+	saveTasks()
+	resetUndo()
+}
+
+//---------Commands-----------------------------
+
+// cmdArchive archives the closed tasks of the selection, or all closed ones, or the ones
+// completed at least the given days ago
+func cmdArchive(g *gocui.Gui, args []string) error {
+	if doc.archiveOf != "" {
+		return fmt.Errorf("already in the archive")
+	}
+
+	var refs []taskRef
+	switch len(args) {
+	case 0:
+		refs = archivable(doc.Projects, 0, time.Now())
+		if selected := selectedTasks(); len(selected) > 0 {
+			refs = nil
+			for _, ref := range selected {
+				if ref.task.Done() && ref.project.Name != archiveSection {
+					refs = append(refs, ref)
+				}
+			}
+		}
+		clearSelection()
+	case 1:
+		days, err := strconv.Atoi(args[0])
+		if err != nil || days < 0 {
+			return fmt.Errorf("usage: archive [days]")
+		}
+		refs = archivable(doc.Projects, days, time.Now())
+	default:
+		return fmt.Errorf("usage: archive [days]")
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	if err := archiveTasks(refs); err != nil {
+		return err
+	}
+	markDirty()
+	// the archive file is written already, the todo file has to follow even
+	// without autosave or the tasks would be in both
+	if doc.Dirty && archiveInFile() {
+		if err := saveTasks(); err != nil {
+			return err
+		}
+		doc.Dirty = false
+	}
+	statusMsg = fmt.Sprintf("%d tasks archived", len(refs))
+	return nil
+}
+
+func cmdRestore(g *gocui.Gui, args []string) error {
+	refs := selectedTasks()
+	if len(refs) == 0 && doc.Projects.Selected != nil && doc.Projects.Selected.Tasks.Selected != nil {
+		refs = []taskRef{{doc.Projects.Selected, doc.Projects.Selected.Tasks.Selected}}
+	}
+	if err := restoreTasks(refs); err != nil {
+		return err
+	}
+	clearSelection()
+	markDirty()
+	return nil
+}
+
+// cmdArchived shows the archive, or goes back to the todo file. Archived
+// tasks are done so hidedone is off while browsing.
+func cmdArchived(g *gocui.Gui, args []string) error {
+	if !archiveInFile() {
+		for _, p := range doc.Projects.Items {
+			if p.Name == archiveSection {
+				doc.Projects.Selected = p
+				p.Tasks.SelectFirst()
+				state = State_Task
+				hidedone = false
+				return nil
+			}
+		}
+		return fmt.Errorf("nothing archived yet")
+	}
+	if globalMode() {
+		return fmt.Errorf("the archives of the global view are next to every todo file")
+	}
+
+	if doc.Dirty {
+		if err := saveTasks(); err != nil {
+			return err
+		}
+		doc.Dirty = false
+	}
+	if doc.archiveOf == "" {
+		doc.archiveOf = doc.Filename
+		doc.archiveHideDone = hidedone
+		hidedone = false
+		openFile(archivePath(doc.Filename))
+		statusMsg = "archive of " + doc.archiveOf + ", :restore brings tasks back"
+	} else {
+		todo := doc.archiveOf
+		doc.archiveOf = ""
+		hidedone = doc.archiveHideDone
+		openFile(todo)
+	}
+	return nil
+}
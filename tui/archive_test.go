@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+func TestArchivable(t *testing.T) {
+	md := "## A\n- [x] old ✅ 2026-01-01\n- [ ] open\n- [x] new ✅ 2026-03-28\n- [x] undated\n" +
+		"## Archive\n- [x] gone ✅ 2025-12-01 [project:: A]\n"
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		days int
+		want string
+	}{
+		{0, "old new undated"},
+		{3, "old new"},
+		{5, "old"},
+		{30, "old"},
+		{100, ""},
+	}
+	for _, tt := range tests {
+		ps, err := mdtodo.ReadFrom(strings.NewReader(md))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, ref := range archivable(ps, tt.days, now) {
+			names = append(names, strings.Fields(ref.task.Name)[0])
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("%d days: got %q, want %q", tt.days, got, tt.want)
+		}
+	}
+}
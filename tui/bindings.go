@@ -0,0 +1,226 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// KeyBindings holds the key mapping
+//
+// The global/task/project tags describe what an action does in that mode and
+// drive the help overlay; an action without any of them is not listed.
+type KeyBindings struct {
+	Quit     string `json:"Quit" global:"Quit mdtodo"`
+	Load     string `json:"Load" global:"Reload the file from disk"`
+	Save     string `json:"Save" global:"Save the file"`
+	ShowDone string `json:"ShowDone" global:"Show or hide done tasks"`
+	Help     string `json:"Help" global:"Show this help"`
+	Command  string `json:"Command" global:"Open the command line"`
+	Details  string `json:"Details" global:"Show or hide the details pane"`
+
+	ShowNotes string `json:"SnowNotes" global:"Show or hide notes"`
+	EditNotes string `json:"EditNotes" task:"Edit the notes of the task in $EDITOR" project:"Edit the notes of the project in $EDITOR"`
+
+	MoveUp    string `json:"MoveUp" task:"Select the next task" project:"Select the next project"`
+	MoveDown  string `json:"MoveDown" task:"Select the previous task" project:"Select the previous project"`
+	ShiftUp   string `json:"ShiftUp" task:"Move the task up" project:"Move the project up"`
+	ShiftDown string `json:"ShiftDown" task:"Move the task down" project:"Move the project down"`
+
+	Delete string `json:"Delete" task:"Delete the task (press twice)" project:"Delete the project (press twice)"`
+
+	AddTask    string `json:"AddTask" task:"Add a task" project:"Add a project"`
+	EditTask   string `json:"EditTask" task:"Rename the task" project:"Rename the project"`
+	TagTask    string `json:"TagTask" task:"Toggle the 🔥 tag"`
+	ToggleTask string `json:"ToggleTask" task:"Move the task to the next state, done and back by default"`
+
+	ModeProject string `json:"ModeProject" task:"Switch to project mode"`
+	ModeTask    string `json:"ModeTask" project:"Switch to task mode"`
+
+	Undo   string `json:"Undo" task:"Undo the last change" project:"Undo the last change"`
+	Redo   string `json:"Redo" task:"Redo the last undone change" project:"Redo the last undone change"`
+	Repeat string `json:"Repeat" task:"Repeat the last change" project:"Repeat the last change"`
+
+	Visual string `json:"Visual" task:"Start or end a range selection"`
+	Mark   string `json:"Mark" task:"Mark or unmark the task for a bulk change"`
+
+	Fold    string `json:"Fold" task:"Fold or unfold the project" project:"Fold or unfold the project"`
+	Outline string `json:"Outline" task:"Show the outline of projects" project:"Show or leave the outline of projects"`
+	Focus   string `json:"Focus" task:"Show only the selected project, or all again" project:"Show only the selected project, or all again"`
+
+	Board      string `json:"Board" task:"Show or hide the board"`
+	BoardLeft  string `json:"BoardLeft" task:"On the board, move the task a column left"`
+	BoardRight string `json:"BoardRight" task:"On the board, move the task a column right"`
+
+	NextTab  string `json:"NextTab" global:"Go to the next tab"`
+	PrevTab  string `json:"PrevTab" global:"Go to the previous tab"`
+	FindFile string `json:"FindFile" global:"Find a markdown file and open it in a new tab"`
+	History  string `json:"History" global:"Show the git history of the file"`
+}
+
+// keyRune is the key of a binding, keys are one character and may be any
+// unicode one
+func keyRune(key string) rune {
+	r, _ := utf8.DecodeRuneInString(key)
+	return r
+}
+
+// Applies non-zero fields from src to dest
+func mergeNonEmptyFields(dest, src interface{}) {
+	destVal := reflect.ValueOf(dest).Elem()
+	srcVal := reflect.ValueOf(src).Elem()
+
+	for i := 0; i < destVal.NumField(); i++ {
+		field := srcVal.Field(i)
+		if !field.IsZero() {
+			destVal.Field(i).Set(field)
+		}
+	}
+}
+
+func defaultKeyBindings() *KeyBindings {
+	return &KeyBindings{
+		Quit:     "q",
+		Load:     "l",
+		Save:     "w",
+		ShowDone: "h",
+		Help:     "?",
+		Command:  ":",
+		Details:  "D",
+
+		ShowNotes: "N",
+		EditNotes: "n",
+
+		Delete: "d",
+
+		MoveUp:    "k",
+		MoveDown:  "j",
+		ShiftUp:   "K",
+		ShiftDown: "J",
+
+		AddTask:    "i",
+		EditTask:   "I",
+		TagTask:    "e",
+		ToggleTask: " ",
+
+		ModeProject: "p",
+		ModeTask:    "t",
+
+		Undo:   "u",
+		Redo:   "U",
+		Repeat: ".",
+
+		Visual: "V",
+		Mark:   "m",
+
+		Fold:    "z",
+		Outline: "o",
+		Focus:   "f",
+
+		Board:      "b",
+		BoardLeft:  "{",
+		BoardRight: "}",
+
+		NextTab:  "]",
+		PrevTab:  "[",
+		FindFile: "F",
+		History:  "H",
+	}
+}
+
+func LoadKeyBindingsWithDefaults(filename string) *KeyBindings {
+	defaults := defaultKeyBindings()
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return defaults
+	}
+	defer file.Close()
+
+	var loaded KeyBindings
+	if err := json.NewDecoder(file).Decode(&loaded); err != nil {
+		fmt.Println("Error decoding keybindings file:", err)
+		return defaults
+	}
+
+	mergeNonEmptyFields(defaults, &loaded)
+	if err := defaults.check(); err != nil {
+		addStatus(err.Error())
+	}
+	return defaults
+}
+
+// fixedKeys are bound by mdtodo itself, no action can take them
+func fixedKeys() map[rune]string {
+	keys := map[rune]string{'<': "Resize", '>': "Resize"}
+	for digit := '0'; digit <= '9'; digit++ {
+		keys[digit] = "Count"
+	}
+	return keys
+}
+
+// check puts back the default of a binding that is not one character or
+// takes the key of an action before it, a default that is taken as well
+// leaves the action unbound
+func (b *KeyBindings) check() error {
+	val := reflect.ValueOf(b).Elem()
+	defaults := reflect.ValueOf(defaultKeyBindings()).Elem()
+	used := fixedKeys()
+	var errs []string
+	for i := 0; i < val.NumField(); i++ {
+		name, key := val.Type().Field(i).Name, val.Field(i).String()
+		if utf8.RuneCountInString(key) != 1 {
+			errs = append(errs, fmt.Sprintf("%s %q is not one character", name, key))
+		} else if other, ok := used[keyRune(key)]; ok {
+			errs = append(errs, fmt.Sprintf("%s %q is the key of %s", name, key, other))
+		} else {
+			used[keyRune(key)] = name
+			continue
+		}
+
+		key = defaults.Field(i).String()
+		if _, ok := used[keyRune(key)]; ok {
+			key = ""
+		} else {
+			used[keyRune(key)] = name
+		}
+		val.Field(i).SetString(key)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %s, ignored", BindingConfig, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func saveKeyBindings(filename string, bindings *KeyBindings) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ") // Pretty-print JSON
+	return encoder.Encode(bindings)
+}
+
+func LoadKeyBindings() *KeyBindings {
+	path, err := mdtodo.UserConfigPath(BindingConfig)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		_ = saveKeyBindings(path, defaultKeyBindings())
+	}
+
+	bindings := LoadKeyBindingsWithDefaults(path)
+
+	return bindings
+}
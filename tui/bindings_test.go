@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyBindingsCheck(t *testing.T) {
+	if err := defaultKeyBindings().check(); err != nil {
+		t.Errorf("the defaults collide: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		change    func(*KeyBindings)
+		check     func(*KeyBindings) string
+		want, err string
+	}{
+		{"taken by an earlier action", func(b *KeyBindings) { b.BoardLeft = "h" }, func(b *KeyBindings) string { return b.BoardLeft }, "{", "BoardLeft \"h\" is the key of ShowDone"},
+		{"default taken too", func(b *KeyBindings) { b.Quit, b.Load = "x", "x" }, func(b *KeyBindings) string { return b.Load }, "l", "Load \"x\" is the key of Quit"},
+		{"default taken too", func(b *KeyBindings) { b.Quit, b.Load = "l", "l" }, func(b *KeyBindings) string { return b.Load }, "", "Load \"l\" is the key of Quit"},
+		{"fixed key", func(b *KeyBindings) { b.Mark = "5" }, func(b *KeyBindings) string { return b.Mark }, "m", "Mark \"5\" is the key of Count"},
+		{"two characters", func(b *KeyBindings) { b.Undo = "uu" }, func(b *KeyBindings) string { return b.Undo }, "u", "Undo \"uu\" is not one character"},
+		{"multibyte", func(b *KeyBindings) { b.TagTask = "🔥" }, func(b *KeyBindings) string { return b.TagTask }, "🔥", ""},
+	}
+	for _, tt := range tests {
+		b := defaultKeyBindings()
+		tt.change(b)
+		err := b.check()
+		if got := tt.check(b); got != tt.want {
+			t.Errorf("%s: key %q, want %q", tt.name, got, tt.want)
+		}
+		if (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jesseduffield/gocui"
+	"github.com/mattn/go-runewidth"
+)
+
+// boardColumn is a column of the board and the tasks in it, top to bottom
+type boardColumn struct {
+	title string
+	refs  []taskRef
+}
+
+var (
+	board = false
+	// "state" for todo/doing/done columns, "project" for a column per project
+	boardBy  = "state"
+	boardCol = 0
+)
+
+var boardStates = []string{"Todo", "Doing", "Done"}
+
+// taskColumn returns the state column of a task, cancelled tasks are with
+// the done ones and deferred or unknown states wait in todo
+func taskColumn(t *Task) int {
+	switch {
+	case t.Done():
+		return 2
+	case t.Status == StatusDoing:
+		return 1
+	}
+	return 0
+}
+
+// setTaskColumn changes the state of a task to the one of the column
+func setTaskColumn(ref taskRef, col int) {
+	ref.setStatus([]Status{StatusOpen, StatusDoing, StatusDone}[col])
+}
+
+func boardColumns() []boardColumn {
+	var cols []boardColumn
+	if boardBy == "project" {
+		for _, p := range doc.Projects.Items {
+			col := boardColumn{title: p.Name}
+			for _, t := range p.Tasks.Items {
+				if !isHidden(t) {
+					col.refs = append(col.refs, taskRef{p, t})
+				}
+			}
+			cols = append(cols, col)
+		}
+		return cols
+	}
+
+	for _, title := range boardStates {
+		cols = append(cols, boardColumn{title: title})
+	}
+	for _, p := range doc.Projects.Items {
+		for _, t := range p.Tasks.Items {
+			if matchesFilter(t) {
+				c := taskColumn(t)
+				cols[c].refs = append(cols[c].refs, taskRef{p, t})
+			}
+		}
+	}
+	return cols
+}
+
+// boardPosition finds the selected task on the board, the row is -1 when
+// it is not in the current column.
+func boardPosition(cols []boardColumn) (int, int) {
+	if boardCol >= len(cols) {
+		boardCol = max(len(cols)-1, 0)
+	}
+	if doc.Projects.Selected == nil || len(cols) == 0 {
+		return boardCol, -1
+	}
+	for row, ref := range cols[boardCol].refs {
+		if ref.task == doc.Projects.Selected.Tasks.Selected {
+			return boardCol, row
+		}
+	}
+	return boardCol, -1
+}
+
+func selectRef(ref taskRef) {
+	doc.Projects.Selected = ref.project
+	ref.project.Tasks.Selected = ref.task
+}
+
+// followSelected points the board at the column the selected task is in
+func followSelected() {
+	if doc.Projects.Selected == nil || doc.Projects.Selected.Tasks.Selected == nil {
+		return
+	}
+	for c, col := range boardColumns() {
+		for _, ref := range col.refs {
+			if ref.task == doc.Projects.Selected.Tasks.Selected {
+				boardCol = c
+				return
+			}
+		}
+	}
+}
+
+func drawBoard(w io.Writer, width int) {
+	cols := boardColumns()
+	if len(cols) == 0 {
+		return
+	}
+	col, row := boardPosition(cols)
+	colWidth := max(width/len(cols)-1, 8)
+
+	rows := 0
+	var header []string
+	for c, column := range cols {
+		title := fmt.Sprintf("%s (%d)", column.title, len(column.refs))
+		if c == col {
+			title = STYLE_LineSelector + " " + title
+		}
+		header = append(header, pad(title, colWidth))
+		rows = max(rows, len(column.refs))
+	}
+	fmt.Fprintln(w, strings.Join(header, " "))
+	fmt.Fprintln(w, strings.Repeat(STYLE_Boldline, width))
+
+	for r := 0; r < rows; r++ {
+		var line []string
+		for c, column := range cols {
+			cell := ""
+			if r < len(column.refs) {
+				t := column.refs[r].task
+				selector := " "
+				if c == col && r == row {
+					selector = STYLE_LineSelector
+				}
+				cell = selector + " " + strings.TrimSpace(t.Tag+" "+t.Name)
+				if boardBy == "state" && len(doc.Projects.Items) > 1 {
+					cell += " " + ansiDim + column.refs[r].project.Name + ansiReset
+				}
+			}
+			line = append(line, pad(cell, colWidth))
+		}
+		fmt.Fprintln(w, strings.Join(line, " "))
+	}
+}
+
+// pad fits s into exactly width cells, escape codes do not take up room
+func pad(s string, width int) string {
+	visible := runewidth.StringWidth(stripAnsi(s))
+	if visible > width {
+		return runewidth.Truncate(stripAnsi(s), width, "…")
+	}
+	return s + strings.Repeat(" ", width-visible)
+}
+
+func stripAnsi(s string) string {
+	var sb strings.Builder
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape && r == 'm':
+			inEscape = false
+		case !inEscape:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+//---------Commands-----------------------------
+
+func cmdBoard(g *gocui.Gui, args []string) error {
+	switch strings.Join(args, " ") {
+	case "":
+		board = !board
+	case "state", "project":
+		board = true
+		boardBy = args[0]
+	default:
+		return fmt.Errorf("usage: board [state|project]")
+	}
+	state = State_Task
+	followSelected()
+	return nil
+}
+
+// boardSelect moves the selection dir rows inside the column
+func boardSelect(dir int) {
+	cols := boardColumns()
+	col, row := boardPosition(cols)
+	if len(cols) == 0 || len(cols[col].refs) == 0 {
+		return
+	}
+	row = min(max(row+dir, 0), len(cols[col].refs)-1)
+	selectRef(cols[col].refs[row])
+}
+
+// boardFocus moves to the column dir away, keeping about the same row
+func boardFocus(dir int) {
+	cols := boardColumns()
+	col, row := boardPosition(cols)
+	boardCol = min(max(col+dir, 0), len(cols)-1)
+	if refs := cols[boardCol].refs; len(refs) > 0 {
+		selectRef(refs[min(max(row, 0), len(refs)-1)])
+	}
+}
+
+// boardMove moves the selected task to the column dir away
+func boardMove(dir int) error {
+	cols := boardColumns()
+	col, row := boardPosition(cols)
+	target := col + dir
+	if row < 0 || target < 0 || target >= len(cols) {
+		return nil
+	}
+	ref := cols[col].refs[row]
+
+	if boardBy == "project" {
+		dest := doc.Projects.Items[target]
+		removeTasks([]taskRef{ref})
+		dest.Tasks.Add(ref.task)
+		doc.Projects.Selected = dest
+	} else {
+		setTaskColumn(ref, target)
+	}
+	boardCol = target
+	markDirty()
+	return nil
+}
+
+// boardShift reorders the selected task inside its column. Neighbours in a
+// state column can be in another project, then the task joins that project
+// next to it.
+func boardShift(dir int) error {
+	cols := boardColumns()
+	col, row := boardPosition(cols)
+	if row < 0 || row+dir < 0 || row+dir >= len(cols[col].refs) {
+		return nil
+	}
+	ref := cols[col].refs[row]
+	other := cols[col].refs[row+dir]
+
+	removeTasks([]taskRef{ref})
+	index, _ := other.project.Tasks.FindIndex(other.task)
+	if dir > 0 {
+		index++
+	}
+	items := other.project.Tasks.Items
+	items = append(items[:index], append([]*Task{ref.task}, items[index:]...)...)
+	other.project.Tasks.Items = items
+	selectRef(taskRef{other.project, ref.task})
+	markDirty()
+	return nil
+}
+
+func cmdColumn(g *gocui.Gui, args []string) error {
+	switch strings.Join(args, " ") {
+	case "left":
+		boardFocus(-1)
+	case "right":
+		boardFocus(+1)
+	default:
+		return fmt.Errorf("usage: column left|right")
+	}
+	return nil
+}
+
+// boardKey runs the command line when the board is shown, otherwise the
+// global key it shadows, if any.
+func boardKey(line string, key string) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if board {
+			return runCounted(g, line, takeCount())
+		}
+		if key == "" {
+			return nil
+		}
+		if h, ok := globalKeys[keyRune(key)]; ok {
+			return h(g, v)
+		}
+		return nil
+	}
+}
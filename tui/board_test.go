@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"testing"
+)
+
+func TestStripAnsi(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[32mgreen\x1b[0m", "green"},
+		{"a \x1b[1;31mred\x1b[0m b", "a red b"},
+		{"🚀 \x1b[2mdim", "🚀 dim"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := stripAnsi(tt.in); got != tt.want {
+			t.Errorf("stripAnsi(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"ab", 4, "ab  "},
+		{"abcd", 4, "abcd"},
+		{"abcdef", 4, "abc…"},
+		{"\x1b[32mab\x1b[0m", 4, "\x1b[32mab\x1b[0m  "},
+		{"\x1b[32mabcdef\x1b[0m", 4, "abc…"},
+		{"🚀x", 4, "🚀x "},
+		{"🚀🚀🚀", 4, "🚀…"},
+	}
+	for _, tt := range tests {
+		if got := pad(tt.in, tt.width); got != tt.want {
+			t.Errorf("pad(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
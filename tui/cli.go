@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+)
+
+// RunCommand handles the sub commands, eg `mdtodo keys`.
+func RunCommand(args []string) error {
+	switch args[0] {
+	case "keys":
+		return writeHelp(os.Stdout, bindingHelpTable(bindings))
+	case "log":
+		return runLog(args[1:])
+	case "list":
+		return runList(args[1:])
+	case "export":
+		return runExport(args[1:])
+	case "import":
+		return runImport(args[1:])
+	case "convert":
+		return runConvert(args[1:])
+	case "ical":
+		return runICal(args[1:])
+	case "html":
+		return runHTML(args[1:])
+	case "serve":
+		return runServe(args[1:])
+	case "global":
+		newTab()
+		if err := openGlobal(); err != nil {
+			return err
+		}
+		return runTUI()
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
@@ -0,0 +1,530 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+// Command is a named action. Key bindings and the command line both run
+// actions through commands, so anything bindable can also be typed.
+type Command struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	Desc    string
+	// Change marks commands that edit the document, the last one is
+	// repeated by the repeat key
+	Change bool
+	// Complete returns the candidates for the argument, may be nil
+	Complete func() []string
+	Run      func(g *gocui.Gui, args []string) error
+}
+
+var commands []*Command
+
+// options that can be changed with `:set`
+var options = map[string]*bool{
+	"hidedone":  &hidedone,
+	"shownotes": &showNotes,
+	"autosave":  &autosave,
+}
+
+func init() {
+	commands = []*Command{
+		{Name: "add", Change: true, Usage: "add [text]", Desc: "Add a task, or a project in project mode", Run: cmdAdd},
+		{Name: "rename", Change: true, Usage: "rename [text]", Desc: "Rename the selected task or project", Run: cmdRename},
+		{Name: "notes", Change: true, Usage: "notes [text]", Desc: "Set the notes of the selected task or project, no text edits them in $EDITOR", Run: cmdNotes},
+		{Name: "delete", Change: true, Desc: "Delete the selected task or project", Run: func(g *gocui.Gui, args []string) error {
+			return deleteSelected()
+		}},
+		{Name: "toggle", Change: true, Desc: "Move the selected task to the next state", Run: handler(toggleTask)},
+		{Name: "status", Change: true, Usage: "status open|done|doing|cancelled|deferred|<char>", Desc: "Set the state of the selected tasks", Complete: mdtodo.StatusNames, Run: cmdStatus},
+		{Name: "tag", Change: true, Desc: "Toggle the 🔥 tag", Run: handler(tagTask)},
+		{Name: "next", Desc: "Select the next item", Run: handler(prev)},
+		{Name: "prev", Desc: "Select the previous item", Run: handler(next)},
+		{Name: "swapup", Change: true, Desc: "Move the selected item up", Run: handler(swapup)},
+		{Name: "swapdown", Change: true, Desc: "Move the selected item down", Run: handler(swapdown)},
+		{Name: "swapleft", Change: true, Desc: "Move the task to the column on the left of the board", Run: func(g *gocui.Gui, args []string) error {
+			return boardMove(-1)
+		}},
+		{Name: "swapright", Change: true, Desc: "Move the task to the column on the right of the board", Run: func(g *gocui.Gui, args []string) error {
+			return boardMove(+1)
+		}},
+		{Name: "column", Usage: "column left|right", Desc: "Select a column of the board", Complete: func() []string {
+			return []string{"left", "right"}
+		}, Run: cmdColumn},
+		{Name: "board", Usage: "board [state|project]", Desc: "Toggle the board, with columns by state or by project", Complete: func() []string {
+			return []string{"state", "project"}
+		}, Run: cmdBoard},
+		{Name: "archive", Usage: "archive [days]", Change: true, Desc: "Archive the selected or all done tasks, or the ones done days ago", Run: cmdArchive},
+		{Name: "archived", Desc: "Browse the archive, or go back to the todo file", Run: cmdArchived},
+		{Name: "restore", Change: true, Desc: "Put the selected archived tasks back into their project", Run: cmdRestore},
+		{Name: "global", Desc: "Show the todo files of all GlobalRoots together", Run: cmdGlobal},
+		{Name: "history", Desc: "Show the git history of the file, to diff or restore a version", Run: cmdHistory},
+		{Name: "plugins", Desc: "List the running plugins", Run: cmdPlugins},
+		{Name: "serve", Usage: "serve [address]", Desc: "Serve the file with a web ui next to the tui", Run: cmdServe},
+		{Name: "today", Desc: "Show only the open tasks due today or earlier, again to show all", Run: cmdToday},
+		{Name: "tabnext", Aliases: []string{"tn"}, Desc: "Go to the next tab", Run: cmdTab(+1)},
+		{Name: "tabprev", Aliases: []string{"tp"}, Desc: "Go to the previous tab", Run: cmdTab(-1)},
+		{Name: "tab", Usage: "tab <number>", Desc: "Go to a tab", Complete: tabNames, Run: cmdTab(0)},
+		{Name: "tabclose", Aliases: []string{"tc"}, Desc: "Save if needed and close the tab", Run: cmdTabClose},
+		{Name: "mode", Usage: "mode task|project", Desc: "Switch between task and project mode", Complete: func() []string {
+			return []string{"task", "project"}
+		}, Run: cmdMode},
+		{Name: "mv", Change: true, Aliases: []string{"move"}, Usage: "mv <project>", Desc: "Move the selected task to another project", Complete: projectNames, Run: cmdMove},
+		{Name: "sort", Change: true, Usage: "sort [key]", Desc: "Sort the tasks of the selected project, or all of them by the sort policy", Complete: mdtodo.SortKeyNames, Run: cmdSort},
+		{Name: "filter", Usage: "filter [text]", Desc: "Only show tasks containing text, no text clears", Run: cmdFilter},
+		{Name: "set", Usage: "set [no]option[!]", Desc: "Change an option, eg hidedone, nohidedone or hidedone!", Complete: optionNames, Run: cmdSet},
+		{Name: "write", Aliases: []string{"w"}, Desc: "Save the file", Run: handler(save)},
+		{Name: "edit", Aliases: []string{"e"}, Usage: "edit [file]", Desc: "Open a file in a new tab, no file reloads the current one", Complete: markdownFiles, Run: cmdEdit},
+		{Name: "fold", Usage: "fold [all|none]", Desc: "Fold or unfold the selected project, or all of them", Complete: func() []string {
+			return []string{"all", "none"}
+		}, Run: cmdFold},
+		{Name: "outline", Desc: "Toggle the outline of projects", Run: handler(toggleOutline)},
+		{Name: "focus", Desc: "Toggle showing only the selected project", Run: handler(toggleFocus)},
+		{Name: "details", Desc: "Show or hide the details pane", Run: handler(toggleDetails)},
+		{Name: "undo", Desc: "Undo the last change", Run: handler(undo)},
+		{Name: "redo", Desc: "Redo the last undone change", Run: handler(redo)},
+		{Name: "help", Desc: "Show the help", Run: handler(toggleHelp)},
+		{Name: "quit", Aliases: []string{"q"}, Desc: "Quit mdtodo", Run: handler(quit)},
+	}
+}
+
+// handler adapts a key handler to a command that takes no arguments
+func handler(h func(*gocui.Gui, *gocui.View) error) func(*gocui.Gui, []string) error {
+	return func(g *gocui.Gui, args []string) error {
+		return h(g, g.CurrentView())
+	}
+}
+
+// bindCommand returns a key handler that runs the command line, as many
+// times as the count typed before the key.
+func bindCommand(line string) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		return runCounted(g, line, takeCount())
+	}
+}
+
+func firstWord(line string) string {
+	name, _, _ := splitCommandInput(line)
+	return name
+}
+
+func commandNames() []string {
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+// findCommand looks up a command by name or alias. It does not guess, a
+// partial name could run a change nobody asked for; fuzzy matching is for
+// completing in the command line.
+func findCommand(name string) *Command {
+	for _, cmd := range commands {
+		if cmd.Name == name || slices.Contains(cmd.Aliases, name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// runCommandLine runs a line like `mv Later Ideas`. Errors are shown in the
+// footer rather than returned, only quitting stops the main loop.
+func runCommandLine(g *gocui.Gui, line string) error {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return nil
+	}
+
+	statusMsg = ""
+	cmd := findCommand(words[0])
+	if cmd == nil {
+		statusMsg = fmt.Sprintf("unknown command: %s", words[0])
+		if matches := fuzzyFilter(words[0], commandNames()); len(matches) > 0 {
+			statusMsg += ", did you mean " + matches[0] + "?"
+		}
+	} else if err := cmd.Run(g, words[1:]); err != nil {
+		if err == gocui.ErrQuit {
+			return err
+		}
+		statusMsg = err.Error()
+	}
+
+	redraw(g)
+	return nil
+}
+
+//---------Commands-----------------------------
+
+func cmdAdd(g *gocui.Gui, args []string) error {
+	if len(args) == 0 {
+		return addView(g, g.CurrentView())
+	}
+	pendingDelete = false
+
+	if err := addItem(strings.Join(args, " ")); err != nil {
+		return err
+	}
+	markDirty()
+	return nil
+}
+
+// addItem adds a task to the selected project, or a project in project mode
+func addItem(name string) error {
+	switch state {
+	case State_Task:
+		p := doc.Projects.Selected
+		if p == nil {
+			return fmt.Errorf("no project to add to")
+		}
+		doc.Notify(mdtodo.EventTaskAdded, p, p.Add(name))
+	case State_Project:
+		doc.Notify(mdtodo.EventProjectAdded, doc.Projects.Add(mdtodo.NewProject(name)), nil)
+	}
+	return nil
+}
+
+func cmdRename(g *gocui.Gui, args []string) error {
+	if len(args) == 0 {
+		return editView(g, g.CurrentView())
+	}
+	pendingDelete = false
+
+	if err := renameItem(strings.Join(args, " ")); err != nil {
+		return err
+	}
+	markDirty()
+	return nil
+}
+
+// renameItem renames the selected task, or project in project mode
+func renameItem(name string) error {
+	p := doc.Projects.Selected
+	switch state {
+	case State_Task:
+		if p == nil || p.Tasks.Selected == nil {
+			return fmt.Errorf("no task selected")
+		}
+		p.Tasks.Selected.Name = name
+		doc.Notify(mdtodo.EventTaskChanged, p, p.Tasks.Selected)
+	case State_Project:
+		if p == nil {
+			return fmt.Errorf("no project selected")
+		}
+		p.Name = name
+		doc.Notify(mdtodo.EventProjectChanged, p, nil)
+	}
+	return nil
+}
+
+// cmdNotes sets the notes of the selected task, or project in project mode
+func cmdNotes(g *gocui.Gui, args []string) error {
+	pendingDelete = false
+	p := doc.Projects.Selected
+	if p == nil {
+		return fmt.Errorf("no project selected")
+	}
+	var t *Task
+	notes := p.Notes
+	if state == State_Task {
+		if t = p.Tasks.Selected; t == nil {
+			return fmt.Errorf("no task selected")
+		}
+		notes = t.Notes
+	}
+
+	if len(args) > 0 {
+		notes = strings.Join(args, " ")
+	} else {
+		var err error
+		if notes, err = editText(g, notes); err != nil {
+			return err
+		}
+	}
+	if t != nil {
+		doc.EditTask(p, t, mdtodo.TaskEdit{Notes: &notes})
+	} else {
+		doc.EditProject(p, nil, &notes)
+	}
+	markDirty()
+	return nil
+}
+
+// editText lets $VISUAL or $EDITOR, vi when neither is set, change the text
+// while the tui is suspended. Blank lines are dropped, the todo file has no
+// room for them in notes.
+func editText(g *gocui.Gui, text string) (string, error) {
+	f, err := os.CreateTemp("", "mdtodo-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(text + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	editor := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := g.Suspend(); err != nil {
+		return "", err
+	}
+	runErr := cmd.Run()
+	if err := g.Resume(); err != nil {
+		return "", err
+	}
+	if runErr != nil {
+		return "", fmt.Errorf("%s: %v", editor[0], runErr)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func cmdMode(g *gocui.Gui, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: mode task|project")
+	}
+	switch args[0] {
+	case "task":
+		state = State_Task
+	case "project":
+		state = State_Project
+	default:
+		return fmt.Errorf("unknown mode: %s", args[0])
+	}
+	return nil
+}
+
+func projectNames() []string {
+	var names []string
+	for _, p := range doc.Projects.Items {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// findProject finds a project by exact name, or the best fuzzy match
+func findProject(name string) *Project {
+	matches := fuzzyFilter(name, projectNames())
+	for _, p := range doc.Projects.Items {
+		if p.Name == name {
+			return p
+		}
+	}
+	for _, p := range doc.Projects.Items {
+		if len(matches) > 0 && p.Name == matches[0] {
+			return p
+		}
+	}
+	return nil
+}
+
+func cmdMove(g *gocui.Gui, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: mv <project>")
+	}
+
+	target := findProject(strings.Join(args, " "))
+	if target == nil {
+		return fmt.Errorf("no project matches %q", strings.Join(args, " "))
+	}
+
+	if refs := selectedTasks(); len(refs) > 0 {
+		bulkMove(refs, target)
+		clearSelection()
+		markDirty()
+		return nil
+	}
+
+	if doc.Projects.Selected == nil || doc.Projects.Selected.Tasks.Selected == nil {
+		return fmt.Errorf("no task selected")
+	}
+	if target == doc.Projects.Selected {
+		return nil
+	}
+
+	task := doc.Projects.Selected.Tasks.Selected
+	doc.Projects.Selected.Tasks.RemoveSelected()
+	target.Tasks.Add(task)
+	markDirty()
+	return nil
+}
+
+func cmdSort(g *gocui.Gui, args []string) error {
+	if len(args) == 0 {
+		if sortPolicy() == nil {
+			return fmt.Errorf("no sort policy, set SortDone or SortBy in config.json")
+		}
+		applySortPolicy(doc.Projects)
+		markDirty()
+		return nil
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: sort %s", strings.Join(mdtodo.SortKeyNames(), "|"))
+	}
+	less, ok := mdtodo.SortKeys[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown sort key: %s", args[0])
+	}
+	if doc.Projects.Selected == nil {
+		return fmt.Errorf("no project selected")
+	}
+	doc.Projects.Selected.SortTasks(less)
+	markDirty()
+	return nil
+}
+
+// maxFiles keeps the completion of :e quick in big folders
+const maxFiles = 500
+
+// todayFilter is the filter of `:today`
+const todayFilter = "@today"
+
+func cmdToday(g *gocui.Gui, args []string) error {
+	if filter == todayFilter {
+		filter = ""
+	} else {
+		filter = todayFilter
+	}
+	return nil
+}
+
+// cmdGlobal opens the global view in its own tab
+func cmdGlobal(g *gocui.Gui, args []string) error {
+	for i, d := range docs {
+		if d.globalFiles != nil {
+			selectTab(i)
+			return nil
+		}
+	}
+	previous := doc
+	newTab()
+	if err := openGlobal(); err != nil {
+		docs = docs[:len(docs)-1]
+		selectTab(tabIndex(previous))
+		return err
+	}
+	return nil
+}
+
+func cmdFilter(g *gocui.Gui, args []string) error {
+	filter = strings.Join(args, " ")
+	return nil
+}
+
+// matchesFilter checks the task against the `:filter` text, ignoring case
+func matchesFilter(t *Task) bool {
+	return taskMatches(t, filter)
+}
+
+func taskMatches(t *Task, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	if filter == todayFilter {
+		return !t.Done() && t.DueBy(time.Now())
+	}
+	// the id is no text to filter on
+	shown := *t
+	shown.ID = ""
+	return strings.Contains(strings.ToLower(shown.String()), strings.ToLower(filter))
+}
+
+func optionNames() []string {
+	var names []string
+	for name := range options {
+		names = append(names, name, "no"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cmdSet works like vim, `set opt` turns it on, `set noopt` off and `set opt!` toggles
+func cmdSet(g *gocui.Gui, args []string) error {
+	if len(args) == 0 {
+		var on []string
+		for _, name := range optionNames() {
+			if opt, ok := options[name]; ok && *opt {
+				on = append(on, name)
+			}
+		}
+		return fmt.Errorf("set: %s", strings.Join(on, " "))
+	}
+
+	for _, arg := range args {
+		name := strings.TrimSuffix(arg, "!")
+		toggle := name != arg
+		value := true
+		if _, ok := options[name]; !ok && strings.HasPrefix(name, "no") {
+			name = strings.TrimPrefix(name, "no")
+			value = false
+		}
+
+		opt, ok := options[name]
+		if !ok {
+			return fmt.Errorf("unknown option: %s", arg)
+		}
+		if toggle {
+			*opt = !*opt
+		} else {
+			*opt = value
+		}
+	}
+	return nil
+}
+
+// markdownFiles lists the markdown files below the current folder, for the
+// completion of :e
+func markdownFiles() []string {
+	var files []string
+	filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != "." && skipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".md") && len(files) < maxFiles {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// cmdEdit opens a file in its own tab, or goes to the tab that has it
+func cmdEdit(g *gocui.Gui, args []string) error {
+	if len(args) == 0 {
+		return load(g, g.CurrentView())
+	}
+
+	openTab(strings.Join(args, " "))
+	return nil
+}
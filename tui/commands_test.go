@@ -0,0 +1,25 @@
+package tui
+
+import "testing"
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"delete", "delete"},
+		{"w", "write"},
+		{"move", "mv"},
+		{"d", ""},
+		{"del", ""},
+		{"wri", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if cmd := findCommand(tt.name); cmd != nil {
+			got = cmd.Name
+		}
+		if got != tt.want {
+			t.Errorf("findCommand(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
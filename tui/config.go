@@ -0,0 +1,6 @@
+package tui
+
+const (
+	BindingConfig  = "keybinding.json"
+	SettingsConfig = "config.json"
+)
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+const (
+	detailViewName = "details"
+	minDetailSize  = 10
+)
+
+// detailsFit reports if the terminal is big enough to show the details
+func detailsFit(maxX, maxY int) bool {
+	if settings.DetailPosition == "bottom" {
+		return maxY >= settings.DetailMinHeight
+	}
+	return maxX >= settings.DetailMinWidth
+}
+
+// layoutDetails sizes the todo view and, when shown, the details pane, both
+// start at line top
+func layoutDetails(g *gocui.Gui, top int) (*gocui.View, error) {
+	maxX, maxY := g.Size()
+	x1, y1 := maxX-1, maxY-4
+
+	if !settings.ShowDetails || !detailsFit(maxX, maxY) {
+		if err := g.DeleteView(detailViewName); err == nil && g.CurrentView() == nil {
+			g.SetCurrentView(viewname)
+		}
+		return g.SetView(viewname, 0, top, x1, y1, 0)
+	}
+
+	var dx0, dy0 int
+	if settings.DetailPosition == "bottom" {
+		size := min(settings.DetailSize, y1-top-minDetailSize)
+		y1 -= size
+		dx0, dy0 = 0, y1+1
+	} else {
+		size := min(settings.DetailSize, x1-minDetailSize)
+		x1 -= size
+		dx0, dy0 = x1+1, top
+	}
+
+	dv, err := g.SetView(detailViewName, dx0, dy0, maxX-1, maxY-4, 0)
+	if err != nil {
+		if !gocui.IsUnknownView(err) {
+			return nil, err
+		}
+		dv.Title = "Details"
+		dv.Wrap = true
+		g.SetKeybinding(detailViewName, gocui.KeyTab, gocui.ModNone, switchPane)
+		g.SetKeybinding(detailViewName, gocui.KeyEsc, gocui.ModNone, switchPane)
+		g.SetKeybinding(detailViewName, 'j', gocui.ModNone, scrollView(1))
+		g.SetKeybinding(detailViewName, gocui.KeyArrowDown, gocui.ModNone, scrollView(1))
+		g.SetKeybinding(detailViewName, 'k', gocui.ModNone, scrollView(-1))
+		g.SetKeybinding(detailViewName, gocui.KeyArrowUp, gocui.ModNone, scrollView(-1))
+		g.SetKeybinding(detailViewName, '<', gocui.ModNone, resizeDetails(+2))
+		g.SetKeybinding(detailViewName, '>', gocui.ModNone, resizeDetails(-2))
+	}
+
+	return g.SetView(viewname, 0, top, x1, y1, 0)
+}
+
+// writeDetails prints everything known about the selected task or project
+func writeDetails(w io.Writer) {
+	p := doc.Projects.Selected
+	if p == nil {
+		return
+	}
+
+	if state == State_Task && p.Tasks.Selected != nil {
+		t := p.Tasks.Selected
+		fmt.Fprintln(w, ansiBold+t.Name+ansiReset)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, ansiDim+"Project"+ansiReset, p.Name)
+		fmt.Fprintln(w, ansiDim+"Status "+ansiReset, glyph(t.Status), styleOf(t.Status).label)
+		if t.Tag != "" {
+			fmt.Fprintln(w, ansiDim+"Tag    "+ansiReset, t.Tag)
+		}
+		if due, ok := t.Due(); ok {
+			fmt.Fprintln(w, ansiDim+"Due    "+ansiReset, due.Format(mdtodo.DateLayout))
+		}
+		if t.Notes != "" {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, renderMarkdown(t.Notes))
+		}
+		return
+	}
+
+	done, total := p.Progress()
+	fmt.Fprintln(w, ansiBold+p.Name+ansiReset)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, ansiDim+"Tasks"+ansiReset, fmt.Sprintf("%d/%d done", done, total))
+	fmt.Fprintln(w, progressBar(done, total, 20))
+	if p.Notes != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, renderMarkdown(p.Notes))
+	}
+}
+
+func redrawDetails(g *gocui.Gui) {
+	if dv, err := g.View(detailViewName); err == nil {
+		dv.Clear()
+		writeDetails(dv)
+	}
+}
+
+//---------Keys-----------------------------
+
+func toggleDetails(g *gocui.Gui, v *gocui.View) error {
+	settings.ShowDetails = !settings.ShowDetails
+	if !settings.ShowDetails && g.CurrentView() != nil && g.CurrentView().Name() == detailViewName {
+		g.SetCurrentView(viewname)
+	}
+	return storeSettings()
+}
+
+// switchPane moves the focus between the list and the details
+func switchPane(g *gocui.Gui, v *gocui.View) error {
+	if v != nil && v.Name() == detailViewName {
+		_, err := g.SetCurrentView(viewname)
+		return err
+	}
+	if _, err := g.View(detailViewName); err != nil {
+		return nil
+	}
+	_, err := g.SetCurrentView(detailViewName)
+	return err
+}
+
+// resizeDetails grows (positive) or shrinks the pane and remembers the size
+func resizeDetails(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		settings.DetailSize = max(settings.DetailSize+delta, minDetailSize)
+		return storeSettings()
+	}
+}
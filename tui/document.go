@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+const tabViewName = "tabs"
+
+// Document is an open todo file with everything the tui keeps about it,
+// every tab holds one
+type Document struct {
+	*mdtodo.Document
+
+	undoStack []snapshot
+	redoStack []snapshot
+	// undoBase is the state after the last change, it becomes the undo step
+	// of the next change
+	undoBase snapshot
+
+	// the todo files shown together by `mdtodo global`, nil for a single file
+	globalFiles []string
+	// what was last read or written per file, unchanged files are not saved
+	globalSaved map[string]string
+
+	// the todo file while its archive file is browsed, empty otherwise
+	archiveOf       string
+	archiveHideDone bool
+	// the archive files before and after the change being made, and the
+	// todo file when tasks are restored into it, recordUndo hands them to
+	// the undo steps
+	archiveBefore map[string]string
+	archiveAfter  map[string]string
+
+	// commits the file after saves, nil when GitCommit is off
+	git *mdtodo.GitCommitter
+}
+
+var (
+	docs []*Document
+	// the document of the current tab
+	doc *Document
+	// start and end column of every tab in the tab bar, for the mouse
+	tabColumns [][2]int
+)
+
+// name is what the tab shows
+func (d *Document) name() string {
+	if d.globalFiles != nil {
+		return "Global"
+	}
+	return filepath.Base(d.Filename)
+}
+
+// newTab adds an empty document and makes it the current one
+func newTab() *Document {
+	return addTab(mdtodo.NewDocument(""))
+}
+
+// runHooks runs the hook of an event, failures go to the footer
+var runHooks = mdtodo.Hooks(showError)
+
+// addTab adds a tab for the document and makes it the current one
+func addTab(md *mdtodo.Document) *Document {
+	md.OnEvent(logDone(func(err error) { statusMsg = err.Error() }))
+	md.OnEvent(runHooks)
+	md.OnEvent(decorationsOutdated)
+	d := &Document{Document: md, git: newGitCommitter()}
+	if d.git != nil {
+		md.OnEvent(d.git.OnEvent)
+	}
+	docs = append(docs, d)
+	selectTab(len(docs) - 1)
+	return d
+}
+
+// openTab switches to the tab of the file, or opens it in a new one
+func openTab(name string) {
+	for i, d := range docs {
+		if d.globalFiles == nil && d.archiveOf == "" && sameFile(d.Filename, name) {
+			selectTab(i)
+			return
+		}
+	}
+	newTab()
+	openFile(name)
+}
+
+func sameFile(a, b string) bool {
+	aa, err1 := filepath.Abs(a)
+	bb, err2 := filepath.Abs(b)
+	return err1 == nil && err2 == nil && aa == bb
+}
+
+func tabIndex(d *Document) int {
+	for i, other := range docs {
+		if other == d {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectTab makes the document of tab i the current one. Marks and the
+// visual range point at tasks of one document so they do not follow.
+func selectTab(i int) {
+	if i < 0 || i >= len(docs) {
+		return
+	}
+	clearSelection()
+	doc = docs[i]
+	refreshDecorations()
+}
+
+// closeTab saves the current document if needed and closes its tab
+func closeTab() error {
+	if len(docs) == 1 {
+		return fmt.Errorf("last tab, :q quits")
+	}
+	if doc.Dirty {
+		if err := saveTasks(); err != nil {
+			return err
+		}
+	}
+	if doc.git != nil {
+		if err := doc.git.Flush(); err != nil {
+			return err
+		}
+	}
+	i := tabIndex(doc)
+	docs = append(docs[:i], docs[i+1:]...)
+	selectTab(min(i, len(docs)-1))
+	return nil
+}
+
+// unsavedTabs lists the documents with changes that are not written yet
+func unsavedTabs() []string {
+	var names []string
+	for _, d := range docs {
+		if d.Dirty {
+			names = append(names, d.name())
+		}
+	}
+	return names
+}
+
+// layoutTabs draws the tab bar above the todo view, it is only there with
+// more than one tab. It returns the first line free for the other views.
+func layoutTabs(g *gocui.Gui) (int, error) {
+	if len(docs) < 2 {
+		g.DeleteView(tabViewName)
+		return 0, nil
+	}
+
+	maxX, _ := g.Size()
+	v, err := g.SetView(tabViewName, -1, -1, maxX, 1, 0)
+	if err != nil {
+		if !gocui.IsUnknownView(err) {
+			return 0, err
+		}
+		v.Frame = false
+		g.SetKeybinding(tabViewName, gocui.MouseLeft, gocui.ModNone, clickTab)
+	}
+
+	v.Clear()
+	tabColumns = tabColumns[:0]
+	x := 0
+	for i, d := range docs {
+		label := fmt.Sprintf(" %d %s ", i+1, d.name())
+		if d.Dirty {
+			label = fmt.Sprintf(" %d %s* ", i+1, d.name())
+		}
+		width := len([]rune(label))
+		tabColumns = append(tabColumns, [2]int{x, x + width})
+		x += width + 1
+
+		if d == doc {
+			label = STYLE_Selected + label + ansiReset
+		} else {
+			label = ansiDim + label + ansiReset
+		}
+		fmt.Fprint(v, label, " ")
+	}
+	return 1, nil
+}
+
+//---------Commands-----------------------------
+
+func clickTab(g *gocui.Gui, v *gocui.View) error {
+	cx, _ := v.Cursor()
+	for i, cols := range tabColumns {
+		if cx >= cols[0] && cx < cols[1] {
+			selectTab(i)
+			g.SetCurrentView(viewname)
+			redraw(g)
+			return nil
+		}
+	}
+	return nil
+}
+
+// cmdTab selects a tab by number, or the next or previous one
+func cmdTab(dir int) func(*gocui.Gui, []string) error {
+	return func(g *gocui.Gui, args []string) error {
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 || n > len(docs) {
+				return fmt.Errorf("usage: tab <1-%d>", len(docs))
+			}
+			selectTab(n - 1)
+			return nil
+		}
+		selectTab((tabIndex(doc) + dir + len(docs)) % len(docs))
+		return nil
+	}
+}
+
+func cmdTabClose(g *gocui.Gui, args []string) error {
+	return closeTab()
+}
+
+func tabNames() []string {
+	var names []string
+	for i, d := range docs {
+		names = append(names, fmt.Sprint(i+1, " ", d.name()))
+	}
+	return names
+}
+
+// findFile opens the command line on `:e ` so Tab fuzzy completes the
+// markdown files below the current folder
+func findFile(g *gocui.Gui, v *gocui.View) error {
+	if err := showCommandLine(g, v); err != nil {
+		return err
+	}
+	iv, err := g.View(cmdViewName)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(iv.TextArea.GetContent()) == "" {
+		iv.TextArea.TypeString("e ")
+		iv.RenderTextArea()
+		completionIndex = -1
+		updateCompletions(g, iv)
+	}
+	return nil
+}
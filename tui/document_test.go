@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+func TestCmdTab(t *testing.T) {
+	tests := []struct {
+		dir     int
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{+1, nil, 2, false},
+		{-1, nil, 0, false},
+		{0, []string{"1"}, 0, false},
+		{0, []string{"3"}, 2, false},
+		{0, []string{"0"}, 1, true},
+		{0, []string{"4"}, 1, true},
+		{0, []string{"99"}, 1, true},
+		{0, []string{"two"}, 1, true},
+	}
+	for _, tt := range tests {
+		docs = nil
+		for _, name := range []string{"a.md", "b.md", "c.md"} {
+			docs = append(docs, &Document{Document: mdtodo.NewDocument(name)})
+		}
+		doc = docs[1]
+		err := cmdTab(tt.dir)(nil, tt.args)
+		if (err != nil) != tt.wantErr || tabIndex(doc) != tt.want {
+			t.Errorf("tab %d %v: on tab %d with %v, want tab %d", tt.dir, tt.args, tabIndex(doc), err, tt.want)
+		}
+	}
+	docs, doc = nil, nil
+}
@@ -0,0 +1,229 @@
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+const logTimeLayout = "2006-01-02 15:04"
+
+// setStatus changes the state of the task, logDone takes care of the done log
+func (r taskRef) setStatus(s Status) {
+	doc.SetStatus(r.project, r.task, s)
+}
+
+// logDone returns a Document listener that writes the completed tasks to
+// the done log, report hears of the errors. It runs wherever the document
+// changes, so it leaves the state of the tui alone.
+func logDone(report func(error)) func(mdtodo.Event) {
+	return func(e mdtodo.Event) {
+		if e.Kind != mdtodo.EventTaskDone {
+			return
+		}
+		todo := e.Project.File
+		if todo == "" {
+			todo = e.Document.Filename
+		}
+		if err := appendDoneLog(todo, e.Project, e.Task, time.Now()); err != nil {
+			report(err)
+		}
+	}
+}
+
+//---------Done log-----------------------------
+
+// doneEntry is a completed task, from the done log or the todo file
+type doneEntry struct {
+	when    time.Time
+	project string
+	name    string
+}
+
+// doneLogPath is where completed tasks are logged, relative paths are next
+// to the todo file. Empty when the log is off.
+func doneLogPath(todo string) string {
+	if settings.DoneLog == "" {
+		return ""
+	}
+	if filepath.IsAbs(settings.DoneLog) {
+		return settings.DoneLog
+	}
+	return filepath.Join(filepath.Dir(todo), settings.DoneLog)
+}
+
+// appendDoneLog adds a line to the done log of the todo file, it is never
+// rewritten
+func appendDoneLog(todo string, p *Project, t *Task, when time.Time) error {
+	path := doneLogPath(todo)
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	name := strings.TrimSpace(mdtodo.DoneRegex.ReplaceAllString(t.Name, ""))
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", when.Format(logTimeLayout), p.Name, name)
+	return err
+}
+
+func readDoneLog(r io.Reader) ([]doneEntry, error) {
+	var entries []doneEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		when, err := time.ParseInLocation(logTimeLayout, fields[0], time.Local)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, doneEntry{when, fields[1], fields[2]})
+	}
+	return entries, scanner.Err()
+}
+
+// doneEntries collects the completion dates written in the todo file
+func doneEntries(ps Projects) []doneEntry {
+	var entries []doneEntry
+	for _, p := range ps.Items {
+		for _, t := range p.Tasks.Items {
+			if when, ok := t.Completed(); ok && t.Status.IsDone() {
+				name := strings.TrimSpace(mdtodo.DoneRegex.ReplaceAllString(t.Name, ""))
+				entries = append(entries, doneEntry{when, p.Name, name})
+			}
+		}
+	}
+	return entries
+}
+
+// mergeDone adds the completion dates of the todo file to the log, for the
+// tasks done before there was a log or while it was off. A task logged on
+// the day of its date is there already.
+func mergeDone(logged, stamped []doneEntry) []doneEntry {
+	key := func(e doneEntry) string {
+		return e.when.Format(mdtodo.DateLayout) + "\t" + e.project + "\t" + e.name
+	}
+	seen := map[string]bool{}
+	for _, e := range logged {
+		seen[key(e)] = true
+	}
+	entries := logged
+	for _, e := range stamped {
+		if !seen[key(e)] {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// parseSince understands today, yesterday, a number of days like 7d and
+// dates, it returns the start of that day
+func parseSince(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch {
+	case s == "today":
+		return today, nil
+	case s == "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case strings.HasSuffix(s, "d"):
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			return today.AddDate(0, 0, -days), nil
+		}
+	}
+	d, err := time.ParseInLocation(mdtodo.DateLayout, s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown date %q, use today, yesterday, 7d or %s", s, mdtodo.DateLayout)
+	}
+	return d, nil
+}
+
+// runLog prints the completed tasks, `mdtodo log --since yesterday`
+func runLog(args []string) error {
+	flags := flag.NewFlagSet("log", flag.ContinueOnError)
+	since := flags.String("since", "", "only tasks completed since today, yesterday, 7d or a date")
+	asJSON := flags.Bool("json", false, "print the tasks as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	todo := mdtodo.DefaultFilename
+	if flags.NArg() > 0 {
+		todo = flags.Arg(0)
+	}
+
+	var logged []doneEntry
+	file, err := os.Open(doneLogPath(todo))
+	if err == nil {
+		defer file.Close()
+		if logged, err = readDoneLog(file); err != nil {
+			return err
+		}
+	}
+	// a log is enough when the todo file is gone
+	ps, err := mdtodo.LoadFile(todo)
+	if err != nil && (file == nil || !errors.Is(err, fs.ErrNotExist)) {
+		return err
+	}
+	entries := mergeDone(logged, doneEntries(ps))
+
+	var from time.Time
+	if *since != "" {
+		if from, err = parseSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].when.Before(entries[j].when)
+	})
+	if *asJSON {
+		return writeDoneJSON(os.Stdout, entries, from)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, e := range entries {
+		if e.when.Before(from) {
+			continue
+		}
+		layout := logTimeLayout
+		if e.when.Hour() == 0 && e.when.Minute() == 0 {
+			layout = mdtodo.DateLayout
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.when.Format(layout), e.project, e.name)
+	}
+	return w.Flush()
+}
+
+// writeDoneJSON prints the entries from the given time on as a JSON list
+func writeDoneJSON(w io.Writer, entries []doneEntry, from time.Time) error {
+	type jsonEntry struct {
+		Completed time.Time `json:"completed"`
+		Project   string    `json:"project"`
+		Name      string    `json:"name"`
+	}
+	list := []jsonEntry{}
+	for _, e := range entries {
+		if !e.when.Before(from) {
+			list = append(list, jsonEntry{e.when, e.project, e.name})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
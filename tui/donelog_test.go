@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"today", time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), false},
+		{"yesterday", time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local), false},
+		{"7d", time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local), false},
+		{"0d", time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), false},
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local), false},
+		{"d", time.Time{}, true},
+		{"last week", time.Time{}, true},
+		{"2026-13-01", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if (err != nil) != tt.err || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadDoneLog(t *testing.T) {
+	log := "2026-10-16 09:15\tMain\twrite report\n" +
+		"not a log line\n" +
+		"yesterday\tMain\tbad time\n" +
+		"2026-10-17 18:00\tLater\tname\twith a tab\n"
+	entries, err := readDoneLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []doneEntry{
+		{time.Date(2026, 10, 16, 9, 15, 0, 0, time.Local), "Main", "write report"},
+		{time.Date(2026, 10, 17, 18, 0, 0, 0, time.Local), "Later", "name\twith a tab"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %v, want %v", entries, want)
+	}
+	for i := range want {
+		if !entries[i].when.Equal(want[i].when) || entries[i].project != want[i].project || entries[i].name != want[i].name {
+			t.Errorf("entry %d = %v, want %v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestMergeDone(t *testing.T) {
+	useDoc(t, "## Main\n- [x] logged ✅ 2026-10-16\n- [x] before the log ✅ 2026-01-02\n- [ ] open\n- [-] cancelled ✅ 2026-10-16\n")
+	logged := []doneEntry{{time.Date(2026, 10, 16, 9, 15, 0, 0, time.Local), "Main", "logged"}}
+	entries := mergeDone(logged, doneEntries(doc.Projects))
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.when.Format(logTimeLayout)+" "+e.name)
+	}
+	if got, want := strings.Join(names, ", "), "2026-10-16 09:15 logged, 2026-01-02 00:00 before the log"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
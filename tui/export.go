@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+//---------Export and import-----------------------------
+
+// runExport prints the todo file with one of the renderers, `mdtodo export --format org`
+func runExport(args []string) error {
+	// plugins may add formats
+	if err := startPlugins(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	defer stopPlugins()
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "json", "output format: "+strings.Join(mdtodo.RendererNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	r, ok := mdtodo.LookupRenderer(*format)
+	if !ok {
+		return fmt.Errorf("unknown format: %s, use %s", *format, strings.Join(mdtodo.RendererNames(), ", "))
+	}
+	ps, err := mdtodo.LoadFile(fileArg(flags))
+	if err != nil {
+		return err
+	}
+	return r.Render(os.Stdout, ps)
+}
+
+// runImport reads a JSON export from a file or stdin into the todo file,
+// `mdtodo import --merge tasks.json`
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	merge := flags.Bool("merge", false, "update and add to the todo file instead of replacing it")
+	target := flags.String("file", mdtodo.DefaultFilename, "the todo file to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "" && name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	e, err := mdtodo.ReadJSON(in)
+	if err != nil {
+		return err
+	}
+
+	d, err := mdtodo.Open(*target)
+	if err != nil {
+		return err
+	}
+	if *merge {
+		err = d.Projects.Merge(e)
+	} else {
+		d.Projects, err = e.ToProjects()
+	}
+	if err != nil {
+		return err
+	}
+	d.Changed()
+	applySortPolicy(d.Projects)
+	return d.Save()
+}
+
+// runList prints the open tasks, `mdtodo list --filter @today --json`
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	match := flags.String("filter", "", "only tasks containing the text, or @today")
+	all := flags.Bool("all", false, "include done and cancelled tasks")
+	asJSON := flags.Bool("json", false, "print the tasks as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ps, err := mdtodo.LoadFile(fileArg(flags))
+	if err != nil {
+		return err
+	}
+
+	// ids are taken before filtering, they depend on the other tasks
+	e := ps.ToJSON()
+	ids := ps.TaskIDs()
+	keep := map[string]bool{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, p := range ps.Items {
+		for _, t := range p.Tasks.Items {
+			if (*all || !t.Done()) && taskMatches(t, *match) {
+				keep[ids[t]] = true
+				fmt.Fprintf(w, "%s\t%s\t%s\n", t.Status.Name(), p.Name, strings.TrimSpace(t.Tag+" "+t.Name))
+			}
+		}
+	}
+	if !*asJSON {
+		return w.Flush()
+	}
+
+	for i, jp := range e.Projects {
+		kept := []mdtodo.JSONTask{}
+		for _, jt := range jp.Tasks {
+			if keep[jt.ID] {
+				kept = append(kept, jt)
+			}
+		}
+		e.Projects[i].Tasks = kept
+	}
+	return e.Write(os.Stdout)
+}
+
+// runConvert copies a todo file into another format, picked by the file
+// extensions, `mdtodo convert todo.md todo.txt`
+func runConvert(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: mdtodo convert <from> <to>")
+	}
+	ps, err := mdtodo.LoadFile(args[0])
+	if err != nil {
+		return err
+	}
+	to := mdtodo.StorageFor(args[1])
+	// converting drops what todo.txt cannot keep, after saying so
+	if _, ok := to.(mdtodo.TodoTxt); ok {
+		if err := mdtodo.TodoTxtNotes(ps); err != nil {
+			fmt.Fprintln(os.Stderr, "warning:", err)
+		}
+		for _, name := range ps.EmptyProjects() {
+			fmt.Fprintf(os.Stderr, "warning: todo.txt has no empty projects, %q is left out\n", name)
+		}
+		to = mdtodo.TodoTxt{Lossy: true}
+	}
+	return to.Save(args[1], ps)
+}
+
+// runICal prints the tasks as an iCalendar file, or merges one back with
+// `mdtodo ical --merge tasks.ics`
+func runICal(args []string) error {
+	flags := flag.NewFlagSet("ical", flag.ContinueOnError)
+	merge := flags.String("merge", "", "update the tasks from this .ics file")
+	dueOnly := flags.Bool("due", false, "only tasks with a due date")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *merge == "" {
+		d, err := mdtodo.Open(fileArg(flags))
+		if err != nil {
+			return err
+		}
+		var keep func(*Task) bool
+		if *dueOnly {
+			keep = func(t *Task) bool {
+				_, ok := t.Due()
+				return ok
+			}
+		}
+		if err := mdtodo.WriteICal(os.Stdout, d.Projects, keep); err != nil {
+			return err
+		}
+		// --merge finds the tasks by these ids later
+		if d.Projects.KeepIDs() {
+			return d.Save()
+		}
+		return nil
+	}
+
+	file, err := os.Open(*merge)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	todos, err := mdtodo.ReadICal(file)
+	if err != nil {
+		return err
+	}
+	d, err := mdtodo.Open(fileArg(flags))
+	if err != nil {
+		return err
+	}
+	n := d.Projects.MergeICal(todos)
+	fmt.Fprintf(os.Stderr, "%d of %d tasks matched\n", n, len(todos))
+	if n == 0 {
+		return nil
+	}
+	d.Changed()
+	applySortPolicy(d.Projects)
+	return d.Save()
+}
+
+// runHTML writes the todo file as a page for reviews and printing,
+// `mdtodo html --hide-done -o report.html`
+func runHTML(args []string) error {
+	flags := flag.NewFlagSet("html", flag.ContinueOnError)
+	hideDone := flags.Bool("hide-done", false, "leave out done and cancelled tasks")
+	title := flags.String("title", "", "page title, Todo by default")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ps, err := mdtodo.LoadFile(fileArg(flags))
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return mdtodo.WriteHTML(out, ps, mdtodo.HTMLOptions{Title: *title, HideDone: *hideDone})
+}
+
+// fileArg is the todo file named on the command line, todo.md by default
+func fileArg(flags *flag.FlagSet) string {
+	if flags.NArg() > 0 {
+		return flags.Arg(0)
+	}
+	return mdtodo.DefaultFilename
+}
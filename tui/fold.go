@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+// ViewState is what we remember about a todo file that does not belong in
+// the markdown, it is kept in a hidden sidecar file next to it.
+type ViewState struct {
+	Folded []string `json:"folded,omitempty"`
+}
+
+var (
+	// outline only lists the projects with their progress
+	outline = false
+	// focus only shows the selected project
+	focus = false
+)
+
+// stateFilename returns the sidecar for a todo file, eg todo.md -> .todo.md.mdtodo.json
+func stateFilename(filename string) string {
+	dir, base := filepath.Split(filename)
+	return filepath.Join(dir, "."+base+"."+mdtodo.ApplicationName+".json")
+}
+
+func loadViewState(filename string) ViewState {
+	var vs ViewState
+	data, err := os.ReadFile(stateFilename(filename))
+	if err != nil {
+		return vs
+	}
+	json.Unmarshal(data, &vs)
+	return vs
+}
+
+func saveViewState(filename string, vs ViewState) error {
+	data, err := json.MarshalIndent(vs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stateFilename(filename), data, 0644)
+}
+
+func foldedNames() []string {
+	var names []string
+	for _, p := range doc.Projects.Items {
+		if p.Folded {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+func applyFolds(names []string) {
+	folded := map[string]bool{}
+	for _, name := range names {
+		folded[name] = true
+	}
+	for _, p := range doc.Projects.Items {
+		p.Folded = folded[p.Name]
+	}
+}
+
+func storeFolds() error {
+	vs := loadViewState(doc.Filename)
+	vs.Folded = foldedNames()
+	return saveViewState(doc.Filename, vs)
+}
+
+// progressBar draws done/total as a bar width runes wide
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+//---------Commands-----------------------------
+
+func cmdFold(g *gocui.Gui, args []string) error {
+	switch strings.Join(args, " ") {
+	case "":
+		if doc.Projects.Selected == nil {
+			return fmt.Errorf("no project selected")
+		}
+		doc.Projects.Selected.Folded = !doc.Projects.Selected.Folded
+	case "all":
+		for _, p := range doc.Projects.Items {
+			p.Folded = true
+		}
+	case "none":
+		for _, p := range doc.Projects.Items {
+			p.Folded = false
+		}
+	default:
+		return fmt.Errorf("usage: fold [all|none]")
+	}
+	return storeFolds()
+}
+
+func toggleOutline(g *gocui.Gui, v *gocui.View) error {
+	outline = !outline
+	if outline {
+		state = State_Project
+	} else {
+		state = State_Task
+	}
+	redraw(g)
+	return nil
+}
+
+func toggleFocus(g *gocui.Gui, v *gocui.View) error {
+	focus = !focus
+	redraw(g)
+	return nil
+}
+
+// zoomProject leaves the outline and focuses the selected project
+func zoomProject(g *gocui.Gui, v *gocui.View) error {
+	if !outline || doc.Projects.Selected == nil {
+		return nil
+	}
+	outline = false
+	focus = true
+	state = State_Task
+	if doc.Projects.Selected.Folded {
+		doc.Projects.Selected.Folded = false
+		storeFolds()
+	}
+	doc.Projects.Selected.Tasks.SelectFirst()
+	redraw(g)
+	return nil
+}
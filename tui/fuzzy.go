@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// fuzzyScore checks if pattern is a case insensitive subsequence of s.
+// Lower scores are better matches: a prefix scores 0 and every skipped rune
+// between matched runes adds one.
+func fuzzyScore(pattern, s string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	s = strings.ToLower(s)
+
+	if strings.HasPrefix(s, pattern) {
+		return 0, true
+	}
+
+	score := 1
+	started := false
+	for _, pr := range pattern {
+		i := strings.IndexRune(s, pr)
+		if i < 0 {
+			return 0, false
+		}
+		if started {
+			score += utf8.RuneCountInString(s[:i])
+		}
+		started = true
+		_, size := utf8.DecodeRuneInString(s[i:])
+		s = s[i+size:]
+	}
+	return score, true
+}
+
+// fuzzyFilter returns the candidates matching pattern, best match first.
+func fuzzyFilter(pattern string, candidates []string) []string {
+	type match struct {
+		value string
+		score int
+	}
+
+	var matches []match
+	for _, c := range candidates {
+		if score, ok := fuzzyScore(pattern, c); ok {
+			matches = append(matches, match{c, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.value
+	}
+	return result
+}
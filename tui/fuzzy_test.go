@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		score      int
+		ok         bool
+	}{
+		{"", "write", 0, true},
+		{"wr", "write", 0, true},
+		{"WR", "write", 0, true},
+		{"wt", "write", 3, true},
+		{"te", "tabnext", 4, true},
+		{"ü", "grün", 1, true},
+		{"üx", "grün", 0, false},
+		{"x", "write", 0, false},
+		{"writes", "write", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyScore(tt.pattern, tt.s)
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) = %d, %v, want %d, %v", tt.pattern, tt.s, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	candidates := []string{"tabnext", "tag", "toggle", "today", "write"}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", candidates},
+		{"ta", []string{"tabnext", "tag", "today"}},
+		{"tg", []string{"tag", "toggle"}},
+		{"to", []string{"toggle", "today"}},
+		{"z", []string{}},
+	}
+	for _, tt := range tests {
+		if got := fuzzyFilter(tt.pattern, candidates); !slices.Equal(got, tt.want) {
+			t.Errorf("fuzzyFilter(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
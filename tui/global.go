@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+func globalMode() bool {
+	return doc.globalFiles != nil
+}
+
+// projectFile is the file a project is saved to
+func projectFile(p *Project) string {
+	if p.File != "" {
+		return p.File
+	}
+	return doc.Filename
+}
+
+// viewTitle is the title of the todo view
+func viewTitle() string {
+	if globalMode() {
+		return fmt.Sprintf("Global, %d files", len(doc.globalFiles))
+	}
+	return doc.Filename
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+// skipDir reports folders that never hold a todo file worth showing
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor"
+}
+
+// findTodoFiles walks the roots, usually the folders holding the git
+// repositories, for files named like GlobalNames
+func findTodoFiles(roots []string) ([]string, error) {
+	names := map[string]bool{}
+	for _, name := range settings.GlobalNames {
+		names[strings.ToLower(name)] = true
+	}
+
+	found := map[string]bool{}
+	for _, root := range roots {
+		root = expandHome(root)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && skipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if names[strings.ToLower(d.Name())] {
+				if abs, err := filepath.Abs(path); err == nil {
+					found[abs] = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]string, 0, len(found))
+	for f := range found {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadGlobal reads every file into one Projects, the projects remember the
+// file they came from
+func loadGlobal(files []string) (Projects, error) {
+	all := mdtodo.NewProjects()
+	doc.globalSaved = map[string]string{}
+	for _, f := range files {
+		ps, err := mdtodo.LoadFile(f)
+		if err != nil {
+			return all, err
+		}
+		for _, p := range ps.Items {
+			p.File = f
+			all.Items = append(all.Items, p)
+		}
+		doc.globalSaved[f] = ps.String()
+	}
+	if len(all.Items) > 0 {
+		all.Selected = all.Items[0]
+	}
+	return all, nil
+}
+
+// openGlobal shows the todo files found in the GlobalRoots together
+func openGlobal() error {
+	if len(settings.GlobalRoots) == 0 {
+		return fmt.Errorf("no GlobalRoots in %s", SettingsConfig)
+	}
+	files, err := findTodoFiles(settings.GlobalRoots)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no todo files in %s", strings.Join(settings.GlobalRoots, ", "))
+	}
+
+	all, err := loadGlobal(files)
+	if err != nil {
+		return err
+	}
+	// folds and the rest of the view state live in the config folder
+	if doc.Filename, err = mdtodo.UserConfigPath("global.md"); err != nil {
+		return err
+	}
+	doc.globalFiles = files
+	doc.archiveOf = ""
+	doc.Projects = all
+	resetUndo()
+	clearSelection()
+	applyFolds(loadViewState(doc.Filename).Folded)
+	autoArchive()
+	refreshDecorations()
+	return nil
+}
+
+// splitByFile groups the projects per file, in the order of globalFiles.
+// New projects belong to the file of the project before them.
+func splitByFile(ps Projects) map[string]*Projects {
+	byFile := map[string]*Projects{}
+	for _, f := range doc.globalFiles {
+		file := mdtodo.NewProjects()
+		byFile[f] = &file
+	}
+
+	last := doc.globalFiles[0]
+	for _, p := range ps.Items {
+		if p.File == "" {
+			p.File = last
+		}
+		last = p.File
+		if byFile[p.File] == nil {
+			file := mdtodo.NewProjects()
+			byFile[p.File] = &file
+		}
+		byFile[p.File].Items = append(byFile[p.File].Items, p)
+	}
+	return byFile
+}
+
+// saveGlobal writes back the files that changed, every one with its own
+// wakatime project from detectProjectName. It does not go through
+// Document.Save, the files belong to other repositories, so GitCommit does
+// not commit them; the on_save hook runs for every file written.
+func saveGlobal(ps Projects) error {
+	// ids are unique across all files
+	ps.AssignIDs()
+	for f, file := range splitByFile(ps) {
+		applySortPolicy(*file)
+		content := file.String()
+		if doc.globalSaved[f] == content {
+			continue
+		}
+		if err := mdtodo.StorageFor(f).Save(f, *file); err != nil {
+			return err
+		}
+		doc.globalSaved[f] = content
+		runHooks(mdtodo.Event{Kind: mdtodo.EventSaved, Document: &mdtodo.Document{Filename: f, Projects: *file}})
+	}
+	return nil
+}
+
+// saveTasks writes the todo file, or every changed file in global mode
+func saveTasks() error {
+	if globalMode() {
+		if err := saveGlobal(doc.Projects); err != nil {
+			return err
+		}
+		doc.Dirty = false
+		return nil
+	}
+	applySortPolicy(doc.Projects)
+	if err := doc.Save(); err != nil {
+		return err
+	}
+	if _, ok := mdtodo.StorageFor(doc.Filename).(mdtodo.TodoTxt); ok {
+		for _, name := range doc.Projects.EmptyProjects() {
+			addStatus(fmt.Sprintf("todo.txt has no empty projects, %q is not saved", name))
+		}
+	}
+	return nil
+}
+
+// displayPath shortens the file for the headers of the global view
+func displayPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
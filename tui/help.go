@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jesseduffield/gocui"
+)
+
+const helpViewName = "help"
+
+// helpModes are the groups shown in the help, in display order. The tag is
+// the KeyBindings struct tag that holds the description for that mode.
+var helpModes = []struct {
+	Name string
+	Tag  string
+}{
+	{"Global", "global"},
+	{"Task", "task"},
+	{"Project", "project"},
+	{"Input", ""},
+}
+
+// keys that are not configurable but still worth knowing about
+var fixedHelp = []bindingHelp{
+	{Mode: "Global", Action: "Quit", Key: "Ctrl+C", Desc: "Quit mdtodo"},
+	{Mode: "Global", Action: "Pane", Key: "Tab", Desc: "Move the focus between the list and the details"},
+	{Mode: "Global", Action: "Resize", Key: "< >", Desc: "Grow or shrink the details pane"},
+	{Mode: "Task", Action: "Count", Key: "0-9", Desc: "Repeat the next key, eg 10J or 3dd"},
+	{Mode: "Task", Action: "Column", Key: "← →", Desc: "On the board, select the column on the left or right"},
+	{Mode: "Task", Action: "Cancel", Key: "Esc", Desc: "Cancel a pending delete and clear the selection"},
+	{Mode: "Project", Action: "Cancel", Key: "Esc", Desc: "Cancel a pending delete and return to task mode"},
+	{Mode: "Project", Action: "Zoom", Key: "Enter", Desc: "In the outline, focus the selected project"},
+	{Mode: "Input", Action: "Confirm", Key: "Enter", Desc: "Accept the input"},
+	{Mode: "Input", Action: "Cancel", Key: "Esc", Desc: "Close the input without changes"},
+	{Mode: "Input", Action: "Complete", Key: "Tab", Desc: "Complete the command line, again for the next match"},
+}
+
+type bindingHelp struct {
+	Mode   string
+	Action string
+	Key    string
+	Desc   string
+}
+
+var helpVisible = false
+
+// bindingHelpTable builds the help rows from the struct tags of KeyBindings,
+// using the keys currently loaded so remapped keys show up correctly.
+func bindingHelpTable(b *KeyBindings) []bindingHelp {
+	var rows []bindingHelp
+
+	val := reflect.ValueOf(b).Elem()
+	typ := val.Type()
+
+	for _, mode := range helpModes {
+		if mode.Tag != "" {
+			for i := 0; i < typ.NumField(); i++ {
+				field := typ.Field(i)
+				desc, ok := field.Tag.Lookup(mode.Tag)
+				if !ok {
+					continue
+				}
+				rows = append(rows, bindingHelp{
+					Mode:   mode.Name,
+					Action: field.Name,
+					Key:    keyLabel(val.Field(i).String()),
+					Desc:   desc,
+				})
+			}
+		}
+		for _, fixed := range fixedHelp {
+			if fixed.Mode == mode.Name {
+				rows = append(rows, fixed)
+			}
+		}
+	}
+
+	for _, cmd := range commands {
+		usage := cmd.Usage
+		if usage == "" {
+			usage = cmd.Name
+		}
+		rows = append(rows, bindingHelp{
+			Mode:   "Commands",
+			Action: strings.Join(append([]string{cmd.Name}, cmd.Aliases...), ","),
+			Key:    ":" + usage,
+			Desc:   cmd.Desc,
+		})
+	}
+	return rows
+}
+
+// keyLabel makes a binding readable, mainly so space is not invisible.
+func keyLabel(key string) string {
+	switch key {
+	case "":
+		return "(unbound)"
+	case " ":
+		return "Space"
+	}
+	return key
+}
+
+// writeHelp prints the help rows as a table grouped by mode.
+func writeHelp(w io.Writer, rows []bindingHelp) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	mode := ""
+	for _, row := range rows {
+		if row.Mode != mode {
+			if mode != "" {
+				fmt.Fprintln(tw)
+			}
+			mode = row.Mode
+			fmt.Fprintf(tw, "%s\n", mode)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", row.Key, row.Action, row.Desc)
+	}
+	return tw.Flush()
+}
+
+//---------Help overlay-----------------------------
+
+func toggleHelp(g *gocui.Gui, v *gocui.View) error {
+	if helpVisible {
+		return closeHelp(g, v)
+	}
+	helpVisible = true
+	return layoutHelp(g)
+}
+
+// layoutHelp creates or resizes the overlay, it is called from layout so it
+// follows terminal resizes.
+func layoutHelp(g *gocui.Gui) error {
+	if !helpVisible {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	hv, err := g.SetView(helpViewName, 2, 1, maxX-3, maxY-2, 0)
+	if err != nil {
+		if !gocui.IsUnknownView(err) {
+			return err
+		}
+		hv.Title = "Help"
+		hv.Subtitle = "j/k scroll, esc close"
+		hv.TitleColor = gocui.ColorYellow
+		hv.FrameColor = gocui.ColorRed
+
+		var sb strings.Builder
+		writeHelp(&sb, bindingHelpTable(bindings))
+		fmt.Fprint(hv, sb.String())
+
+		if _, err := g.SetCurrentView(helpViewName); err != nil {
+			return err
+		}
+		g.SetKeybinding(helpViewName, gocui.KeyEsc, gocui.ModNone, closeHelp)
+		g.SetKeybinding(helpViewName, 'q', gocui.ModNone, closeHelp)
+		g.SetKeybinding(helpViewName, keyRune(bindings.Help), gocui.ModNone, closeHelp)
+		g.SetKeybinding(helpViewName, 'j', gocui.ModNone, scrollView(1))
+		g.SetKeybinding(helpViewName, gocui.KeyArrowDown, gocui.ModNone, scrollView(1))
+		g.SetKeybinding(helpViewName, 'k', gocui.ModNone, scrollView(-1))
+		g.SetKeybinding(helpViewName, gocui.KeyArrowUp, gocui.ModNone, scrollView(-1))
+		g.SetKeybinding(helpViewName, gocui.KeyPgdn, gocui.ModNone, scrollView(10))
+		g.SetKeybinding(helpViewName, gocui.KeyPgup, gocui.ModNone, scrollView(-10))
+	}
+	return nil
+}
+
+// scrollView scrolls a view by dir lines without going past its content
+func scrollView(dir int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		maxOrigin := v.LinesHeight() - v.InnerHeight()
+		y := v.OriginY() + dir
+		if y > maxOrigin {
+			y = maxOrigin
+		}
+		if y < 0 {
+			y = 0
+		}
+		return v.SetOriginY(y)
+	}
+}
+
+func closeHelp(g *gocui.Gui, hv *gocui.View) error {
+	helpVisible = false
+	g.DeleteViewKeybindings(helpViewName)
+	if err := g.DeleteView(helpViewName); err != nil && !gocui.IsUnknownView(err) {
+		return err
+	}
+	if _, err := g.SetCurrentView(viewname); err != nil {
+		return err
+	}
+	redraw(g)
+	return nil
+}
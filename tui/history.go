@@ -0,0 +1,221 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+//---------Auto commit-----------------------------
+
+// newGitCommitter is nil unless GitCommit is set
+func newGitCommitter() *mdtodo.GitCommitter {
+	if settings == nil || !settings.GitCommit {
+		return nil
+	}
+	c := mdtodo.NewGitCommitter(time.Duration(settings.GitCommitDelay) * time.Second)
+	c.OnError = showError
+	return c
+}
+
+// flushGit commits what is still waiting for its delay, before quitting
+func flushGit() error {
+	var errs []error
+	for _, d := range docs {
+		if d.git != nil {
+			errs = append(errs, d.git.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+//---------History-----------------------------
+
+const (
+	historyViewName = "history"
+	historyLimit    = 200
+
+	ansiAdded   = "\x1b[32m"
+	ansiRemoved = "\x1b[31m"
+)
+
+var (
+	historyVisible  = false
+	historyVersions []mdtodo.GitVersion
+	historyIndex    = 0
+	// the diff of the selected version, shown instead of the list when set
+	historyDiff = ""
+)
+
+func cmdHistory(g *gocui.Gui, args []string) error {
+	if historyVisible {
+		return closeHistory(g, nil)
+	}
+	if globalMode() || doc.Filename == "" {
+		return fmt.Errorf("only a todo file has a history")
+	}
+	versions, err := mdtodo.GitLog(doc.Filename, historyLimit)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("%s has no commits", doc.name())
+	}
+	historyVersions = versions
+	historyIndex = 0
+	historyDiff = ""
+	historyVisible = true
+	return layoutHistory(g)
+}
+
+// layoutHistory creates or resizes the overlay like layoutHelp, and draws
+// the list of versions or the diff of one
+func layoutHistory(g *gocui.Gui) error {
+	if !historyVisible {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	hv, err := g.SetView(historyViewName, 2, 1, maxX-3, maxY-2, 0)
+	if err != nil {
+		if !gocui.IsUnknownView(err) {
+			return err
+		}
+		hv.TitleColor = gocui.ColorYellow
+		hv.FrameColor = gocui.ColorRed
+		if _, err := g.SetCurrentView(historyViewName); err != nil {
+			return err
+		}
+		g.SetKeybinding(historyViewName, gocui.KeyEsc, gocui.ModNone, historyBack)
+		g.SetKeybinding(historyViewName, 'q', gocui.ModNone, historyBack)
+		g.SetKeybinding(historyViewName, keyRune(bindings.History), gocui.ModNone, closeHistory)
+		g.SetKeybinding(historyViewName, 'j', gocui.ModNone, historyMove(1))
+		g.SetKeybinding(historyViewName, gocui.KeyArrowDown, gocui.ModNone, historyMove(1))
+		g.SetKeybinding(historyViewName, 'k', gocui.ModNone, historyMove(-1))
+		g.SetKeybinding(historyViewName, gocui.KeyArrowUp, gocui.ModNone, historyMove(-1))
+		g.SetKeybinding(historyViewName, gocui.KeyPgdn, gocui.ModNone, historyMove(10))
+		g.SetKeybinding(historyViewName, gocui.KeyPgup, gocui.ModNone, historyMove(-10))
+		g.SetKeybinding(historyViewName, 'd', gocui.ModNone, showHistoryDiff)
+		g.SetKeybinding(historyViewName, gocui.KeyEnter, gocui.ModNone, showHistoryDiff)
+		g.SetKeybinding(historyViewName, 'r', gocui.ModNone, restoreHistory)
+	}
+
+	hv.Clear()
+	version := historyVersions[historyIndex]
+	if historyDiff != "" {
+		hv.Title = "Diff from " + version.Hash[:7] + " to " + doc.name()
+		hv.Subtitle = "j/k scroll, r restore, esc back"
+		for _, line := range strings.Split(strings.TrimRight(historyDiff, "\n"), "\n") {
+			fmt.Fprintln(hv, diffLine(line))
+		}
+		return nil
+	}
+
+	hv.Title = "History of " + doc.name()
+	hv.Subtitle = "j/k select, d diff, r restore, esc close"
+	for i, v := range historyVersions {
+		selector := " "
+		if i == historyIndex {
+			selector = STYLE_LineSelector
+		}
+		fmt.Fprintf(hv, "%s %s  %s  %s\n", selector, v.Date.Local().Format("2006-01-02 15:04"), v.Hash[:7], v.Subject)
+	}
+	// keep the selected version on screen
+	if oy := hv.OriginY(); historyIndex < oy {
+		hv.SetOriginY(historyIndex)
+	} else if height := hv.InnerHeight(); historyIndex >= oy+height {
+		hv.SetOriginY(historyIndex - height + 1)
+	}
+	return nil
+}
+
+func diffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return ansiBold + line + ansiReset
+	case strings.HasPrefix(line, "+"):
+		return ansiAdded + line + ansiReset
+	case strings.HasPrefix(line, "-"):
+		return ansiRemoved + line + ansiReset
+	case strings.HasPrefix(line, "@@"):
+		return ansiCode + line + ansiReset
+	}
+	return line
+}
+
+// historyMove selects another version, or scrolls the diff
+func historyMove(dir int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if historyDiff != "" {
+			return scrollView(dir)(g, v)
+		}
+		historyIndex = max(0, min(historyIndex+dir, len(historyVersions)-1))
+		return layoutHistory(g)
+	}
+}
+
+func showHistoryDiff(g *gocui.Gui, v *gocui.View) error {
+	diff, err := mdtodo.GitDiff(doc.Filename, historyVersions[historyIndex].Hash)
+	if err != nil {
+		statusMsg = err.Error()
+		redraw(g)
+		return nil
+	}
+	if diff == "" {
+		diff = "no changes"
+	}
+	historyDiff = diff
+	v.SetOrigin(0, 0)
+	return layoutHistory(g)
+}
+
+// restoreHistory replaces the projects with the selected version, as a
+// change that undo takes back
+func restoreHistory(g *gocui.Gui, v *gocui.View) error {
+	version := historyVersions[historyIndex]
+	ps, err := mdtodo.LoadGitVersion(doc.Filename, version.Hash)
+	if err != nil {
+		statusMsg = err.Error()
+		redraw(g)
+		return nil
+	}
+	folded := foldedNames()
+	doc.Projects = ps
+	applyFolds(folded)
+	clearSelection()
+	markDirty()
+	if err := closeHistory(g, v); err != nil {
+		return err
+	}
+	statusMsg = "restored " + version.Hash[:7] + " from " + version.Date.Local().Format("2006-01-02 15:04") + ", undo goes back"
+	redraw(g)
+	return nil
+}
+
+// historyBack leaves the diff for the list, or closes the history
+func historyBack(g *gocui.Gui, v *gocui.View) error {
+	if historyDiff != "" {
+		historyDiff = ""
+		v.SetOrigin(0, 0)
+		return layoutHistory(g)
+	}
+	return closeHistory(g, v)
+}
+
+func closeHistory(g *gocui.Gui, v *gocui.View) error {
+	historyVisible = false
+	historyVersions = nil
+	historyDiff = ""
+	g.DeleteViewKeybindings(historyViewName)
+	if err := g.DeleteView(historyViewName); err != nil && !gocui.IsUnknownView(err) {
+		return err
+	}
+	if _, err := g.SetCurrentView(viewname); err != nil {
+		return err
+	}
+	redraw(g)
+	return nil
+}
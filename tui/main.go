@@ -0,0 +1,740 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+//---------- AppState-------------------
+
+type AppState int
+
+const (
+	State_Task AppState = iota
+	State_Project
+)
+
+func (s AppState) String() string {
+	return [...]string{"Task", "Proj"}[s]
+}
+
+//---------- Main-------------------
+
+const (
+	STYLE_Checked      = "☐"
+	STYLE_UnChecked    = "\U0001f5f9"
+	STYLE_LineSelector = "»"
+	STYLE_HasNotes     = "🗒️"
+	STYLE_Boldline     = "━"
+	STYLE_Thinline     = "―"
+	STYLE_Selected     = "\x1b[7m"
+	STYLE_Folded       = "▸"
+	STYLE_Doing        = "◐"
+	STYLE_Cancelled    = "⊘"
+	STYLE_Deferred     = "↷"
+)
+
+var (
+	bindings      *KeyBindings
+	state         AppState = State_Task
+	viewname               = "todo"
+	autosave               = true
+	hidedone               = true
+	pendingDelete          = false
+	showNotes              = false
+	filter                 = ""
+	statusMsg              = ""
+)
+
+// LoadConfig reads the key bindings and settings, call it before Run or
+// RunCommand
+func LoadConfig() {
+	bindings = LoadKeyBindings()
+	settings = LoadSettings()
+}
+
+// Run shows the document in the terminal until the user quits. A document
+// without unsaved changes is read (again) from its file, after the tui
+// listens to it.
+func Run(d *mdtodo.Document) error {
+	addTab(d)
+	if !d.Dirty {
+		if err := d.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	opened()
+	return runTUI()
+}
+
+func runTUI() error {
+	g, err := gocui.NewGui(gocui.NewGuiOpts{
+		OutputMode: gocui.OutputTrue,
+		//RuneReplacements: map[rune]string{},
+	})
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	g.Mouse = true
+	g.SetManagerFunc(layout)
+
+	if err := startPlugins(); err != nil {
+		statusMsg = err.Error()
+	}
+	defer stopPlugins()
+	gui = g
+	decorate()
+
+	if settings.Serve != "" {
+		if err := serveTab(g, settings.Serve); err != nil {
+			statusMsg = err.Error()
+		}
+	}
+
+	bindGlobal(g, bindings.Save, bindCommand("write"))
+	bindGlobal(g, bindings.Load, bindCommand("edit"))
+	bindGlobal(g, bindings.ShowDone, bindCommand("set hidedone!"))
+	bindGlobal(g, bindings.ShowNotes, bindCommand("set shownotes!"))
+	bindGlobal(g, bindings.Help, bindCommand("help"))
+	bindGlobal(g, bindings.Command, showCommandLine)
+	bindGlobal(g, bindings.Details, bindCommand("details"))
+	bindGlobal(g, bindings.NextTab, bindCommand("tabnext"))
+	bindGlobal(g, bindings.PrevTab, bindCommand("tabprev"))
+	bindGlobal(g, bindings.FindFile, findFile)
+	bindGlobal(g, bindings.History, bindCommand("history"))
+
+	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
+		return err
+	}
+	if err := bindGlobal(g, bindings.Quit, bindCommand("quit")); err != nil {
+		return err
+	}
+
+	err = g.MainLoop()
+	if err != nil && err != gocui.ErrQuit {
+		return err
+	}
+	// the gui is closed when the caller prints the error
+	return flushGit()
+}
+
+// gui is the tui once it runs, goroutines report back through its Update
+var gui *gocui.Gui
+
+// addStatus puts a message in the footer next to the ones already there,
+// for the checks of the config files
+func addStatus(msg string) {
+	if statusMsg != "" {
+		statusMsg += "; "
+	}
+	statusMsg += msg
+}
+
+// showError puts an error of a goroutine in the footer
+func showError(err error) {
+	if gui != nil {
+		gui.Update(func(*gocui.Gui) error {
+			statusMsg = err.Error()
+			return nil
+		})
+	}
+}
+
+// globalKeys holds the handlers of the global keys, so a view key that
+// shadows one can fall back to it when it does not apply
+var globalKeys = map[rune]func(*gocui.Gui, *gocui.View) error{}
+
+func bindGlobal(g *gocui.Gui, key string, h func(*gocui.Gui, *gocui.View) error) error {
+	globalKeys[keyRune(key)] = h
+	return g.SetKeybinding("", keyRune(key), gocui.ModNone, h)
+}
+
+// openFile loads a todo file and resets everything that belonged to the
+// previous one
+func openFile(name string) {
+	doc.Filename = name
+	doc.Load()
+	opened()
+}
+
+// opened sets up the current tab for the document it just got
+func opened() {
+	resetUndo()
+	clearSelection()
+	applyFolds(loadViewState(doc.Filename).Folded)
+	autoArchive()
+}
+
+// markDirty records an undo step and saves if autosave is on. Inside a
+// batch it only notes the change, endBatch then calls it once.
+func markDirty() {
+	if batchDepth > 0 {
+		batchDirty = true
+		return
+	}
+	recordUndo()
+	flushDirty()
+}
+
+func flushDirty() {
+	doc.Changed()
+	if autosave {
+		if err := saveTasks(); err != nil {
+			statusMsg = err.Error()
+		}
+	}
+}
+
+func layout(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+
+	top, err := layoutTabs(g)
+	if err != nil {
+		return err
+	}
+	if v, err := layoutDetails(g, top); err != nil {
+		if !gocui.IsUnknownView(err) {
+			return err
+		}
+		v.Title = viewTitle()
+
+		if _, err := g.SetCurrentView(viewname); err != nil {
+			return err
+		}
+		todoBinding(g)
+	}
+
+	if v, err := g.SetView("footer", 0, maxY-3, maxX-1, maxY-1, 0); err != nil {
+		v.Frame = false
+	}
+	if err := layoutCommandLine(g); err != nil {
+		return err
+	}
+	if err := layoutHelp(g); err != nil {
+		return err
+	}
+	if err := layoutHistory(g); err != nil {
+		return err
+	}
+	redraw(g)
+	return nil
+}
+
+//---------Key binds-----------------------------
+
+func deleteSelected() error {
+
+	switch state {
+	case State_Task:
+		if refs := selectedTasks(); len(refs) > 0 {
+			removeTasks(refs)
+			clearSelection()
+			for _, r := range refs {
+				doc.Notify(mdtodo.EventTaskRemoved, r.project, r.task)
+			}
+		} else if p := doc.Projects.Selected; p != nil && p.Tasks.Selected != nil {
+			t := p.Tasks.Selected
+			p.Tasks.RemoveSelected()
+			doc.Notify(mdtodo.EventTaskRemoved, p, t)
+		}
+	case State_Project:
+		if p := doc.Projects.Selected; p != nil {
+			doc.Projects.RemoveSelected()
+			doc.Notify(mdtodo.EventProjectRemoved, p, nil)
+		}
+	}
+
+	pendingDelete = false
+	markDirty()
+	return nil
+}
+
+func next(g *gocui.Gui, v *gocui.View) error {
+	switch state {
+	case State_Task:
+		if board {
+			boardSelect(-1)
+		} else if doc.Projects.Selected != nil {
+
+			p := doc.Projects.Selected.Tasks.Selected
+			if p == doc.Projects.Selected.Select(-1, hidedone) && !focus {
+				doc.Projects.Select(-1)
+				doc.Projects.Selected.Tasks.SelectLast()
+			}
+		}
+	case State_Project:
+		doc.Projects.Select(-1)
+	}
+
+	if pendingDelete {
+		deleteSelected()
+		prev(g, v)
+	}
+	redraw(g)
+	return nil
+}
+
+func prev(g *gocui.Gui, v *gocui.View) error {
+	switch state {
+	case State_Task:
+		if board {
+			boardSelect(+1)
+		} else if doc.Projects.Selected != nil {
+			p := doc.Projects.Selected.Tasks.Selected
+
+			if p == doc.Projects.Selected.Select(+1, hidedone) && !focus {
+				doc.Projects.Select(+1)
+				doc.Projects.Selected.Tasks.SelectFirst()
+			}
+		}
+	case State_Project:
+		doc.Projects.Select(+1)
+	}
+
+	if pendingDelete {
+		deleteSelected()
+	}
+	redraw(g)
+	return nil
+}
+
+func swapup(g *gocui.Gui, v *gocui.View) error {
+	switch state {
+	case State_Task:
+		if board {
+			boardShift(-1)
+		} else if refs := selectedTasks(); len(refs) > 0 {
+			bulkShift(refs, -1)
+			markDirty()
+		} else if doc.Projects.Selected != nil {
+			doc.Projects.Selected.MoveSelected(-1, hidedone)
+			markDirty()
+		}
+	case State_Project:
+		doc.Projects.MoveSelected(-1)
+		markDirty()
+
+	}
+
+	redraw(g)
+	return nil
+}
+
+func swapdown(g *gocui.Gui, v *gocui.View) error {
+	switch state {
+	case State_Task:
+		if board {
+			boardShift(+1)
+		} else if refs := selectedTasks(); len(refs) > 0 {
+			bulkShift(refs, +1)
+			markDirty()
+		} else if doc.Projects.Selected != nil {
+			doc.Projects.Selected.MoveSelected(+1, hidedone)
+			markDirty()
+		}
+	case State_Project:
+		doc.Projects.MoveSelected(+1)
+		markDirty()
+
+	}
+
+	redraw(g)
+	return nil
+}
+
+func quit(g *gocui.Gui, v *gocui.View) error {
+	return gocui.ErrQuit
+}
+
+func cancel(g *gocui.Gui, v *gocui.View) error {
+	state = State_Task
+	pendingDelete = false
+	count = 0
+	outline = false
+	clearSelection()
+	statusMsg = ""
+	redraw(g)
+	return nil
+}
+
+func toggleTask(g *gocui.Gui, v *gocui.View) error {
+	if refs := selectedTasks(); len(refs) > 0 {
+		bulkToggle(refs)
+		clearSelection()
+		markDirty()
+	} else if (doc.Projects.Selected != nil) && (doc.Projects.Selected.Tasks.Selected != nil) {
+		ref := taskRef{doc.Projects.Selected, doc.Projects.Selected.Tasks.Selected}
+		ref.setStatus(nextStatus(ref.task.Status))
+		markDirty()
+	}
+	redraw(g)
+	return nil
+}
+
+func tagTask(g *gocui.Gui, v *gocui.View) error {
+	if refs := selectedTasks(); len(refs) > 0 {
+		bulkTag(refs)
+		clearSelection()
+		markDirty()
+	} else if (doc.Projects.Selected != nil) && (doc.Projects.Selected.Tasks.Selected != nil) {
+		if doc.Projects.Selected.Tasks.Selected.Tag == "" {
+			doc.Projects.Selected.Tasks.Selected.Tag = "🔥"
+		} else {
+			doc.Projects.Selected.Tasks.Selected.Tag = ""
+		}
+		markDirty()
+	}
+	redraw(g)
+	return nil
+}
+
+func save(g *gocui.Gui, v *gocui.View) error {
+	if err := saveTasks(); err != nil {
+		statusMsg = err.Error()
+	}
+	redraw(g)
+	return nil
+}
+
+func load(g *gocui.Gui, v *gocui.View) error {
+	if globalMode() {
+		if err := openGlobal(); err != nil {
+			return err
+		}
+	} else {
+		openFile(doc.Filename)
+	}
+	redraw(g)
+	return nil
+}
+
+//--------------------------------------
+
+func todoBinding(g *gocui.Gui) error {
+	var v *gocui.View
+	v, _ = g.View(viewname)
+
+	if v == nil {
+		return nil
+	}
+
+	g.SetKeybinding(viewname, gocui.KeyEsc, gocui.ModNone, cancel)
+
+	g.SetKeybinding(viewname, keyRune(bindings.ShiftUp), gocui.ModNone, bindCommand("swapup"))
+	g.SetKeybinding(viewname, keyRune(bindings.ShiftDown), gocui.ModNone, bindCommand("swapdown"))
+	g.SetKeybinding(viewname, keyRune(bindings.MoveUp), gocui.ModNone, bindCommand("next"))
+	g.SetKeybinding(viewname, keyRune(bindings.MoveDown), gocui.ModNone, bindCommand("prev"))
+	g.SetKeybinding(viewname, keyRune(bindings.AddTask), gocui.ModNone, bindCommand("add"))
+	g.SetKeybinding(viewname, keyRune(bindings.EditTask), gocui.ModNone, bindCommand("rename"))
+	g.SetKeybinding(viewname, keyRune(bindings.EditNotes), gocui.ModNone, bindCommand("notes"))
+	g.SetKeybinding(viewname, keyRune(bindings.TagTask), gocui.ModNone, bindCommand("tag"))
+	g.SetKeybinding(viewname, keyRune(bindings.ModeProject), gocui.ModNone, bindCommand("mode project"))
+	g.SetKeybinding(viewname, keyRune(bindings.ModeTask), gocui.ModNone, bindCommand("mode task"))
+
+	var toggleBind interface{}
+	toggleBind = gocui.KeySpace
+	if bindings.ToggleTask != " " {
+		toggleBind = keyRune(bindings.ToggleTask)
+	}
+
+	g.SetKeybinding(viewname, toggleBind, gocui.ModNone, bindCommand("toggle"))
+	g.SetKeybinding(viewname, keyRune(bindings.Undo), gocui.ModNone, bindCommand("undo"))
+	g.SetKeybinding(viewname, keyRune(bindings.Redo), gocui.ModNone, bindCommand("redo"))
+	g.SetKeybinding(viewname, keyRune(bindings.Repeat), gocui.ModNone, repeatChange)
+	g.SetKeybinding(viewname, keyRune(bindings.Visual), gocui.ModNone, toggleVisual)
+	g.SetKeybinding(viewname, keyRune(bindings.Mark), gocui.ModNone, toggleMark)
+	g.SetKeybinding(viewname, keyRune(bindings.Fold), gocui.ModNone, bindCommand("fold"))
+	g.SetKeybinding(viewname, keyRune(bindings.Outline), gocui.ModNone, bindCommand("outline"))
+	g.SetKeybinding(viewname, keyRune(bindings.Focus), gocui.ModNone, bindCommand("focus"))
+	g.SetKeybinding(viewname, gocui.KeyEnter, gocui.ModNone, zoomProject)
+
+	g.SetKeybinding(viewname, gocui.KeyTab, gocui.ModNone, switchPane)
+	g.SetKeybinding(viewname, '<', gocui.ModNone, resizeDetails(+2))
+	g.SetKeybinding(viewname, '>', gocui.ModNone, resizeDetails(-2))
+
+	g.SetKeybinding(viewname, keyRune(bindings.BoardLeft), gocui.ModNone, boardKey("swapleft", bindings.BoardLeft))
+	g.SetKeybinding(viewname, keyRune(bindings.BoardRight), gocui.ModNone, boardKey("swapright", bindings.BoardRight))
+	g.SetKeybinding(viewname, keyRune(bindings.Board), gocui.ModNone, bindCommand("board"))
+	g.SetKeybinding(viewname, gocui.KeyArrowLeft, gocui.ModNone, boardKey("column left", ""))
+	g.SetKeybinding(viewname, gocui.KeyArrowRight, gocui.ModNone, boardKey("column right", ""))
+
+	mouseBinding(g)
+	bindPlugins(g)
+
+	for digit := 0; digit <= 9; digit++ {
+		g.SetKeybinding(viewname, rune('0'+digit), gocui.ModNone, countDigit(digit))
+	}
+
+	g.SetKeybinding(viewname, keyRune(bindings.Delete), gocui.ModNone, func(g *gocui.Gui, cv *gocui.View) error {
+		if pendingDelete {
+			return runCounted(g, "delete", takeCount())
+		}
+		pendingDelete = true
+		redraw(g)
+		return nil
+	})
+
+	return nil
+}
+
+func redraw(g *gocui.Gui) {
+	maxX, _ := g.Size()
+	doneCount := 0
+	taskCount := 0
+	selected := map[*Task]bool{}
+	for _, ref := range selectedTasks() {
+		selected[ref.task] = true
+	}
+	if v, e := g.View(viewname); e == nil {
+		v.Title = viewTitle()
+		v.Clear()
+		screenLines = screenLines[:0]
+		selectedLine = -1
+		if board {
+			drawBoard(v, maxX-2)
+		}
+		lastFile := ""
+		for _, group := range doc.Projects.Items {
+			groupDone, groupCount := group.Progress()
+			doneCount += groupDone
+			taskCount += groupCount
+
+			if board || (focus && group != doc.Projects.Selected) {
+				continue
+			}
+
+			if file := projectFile(group); globalMode() && file != lastFile && group.File != "" {
+				writeLine(v, group, nil, "\n"+ansiBold+displayPath(file)+ansiReset)
+				lastFile = file
+			}
+
+			selector := " "
+			if group == doc.Projects.Selected {
+				selector = STYLE_LineSelector
+			}
+
+			if outline {
+				writeLine(v, group, nil, fmt.Sprintf("%s %-30s %s %d/%d", selector, group.Name, progressBar(groupDone, groupCount, 20), groupDone, groupCount))
+				continue
+			}
+
+			noteIcon := ""
+			if !showNotes && group.Notes != "" {
+				noteIcon = STYLE_HasNotes
+			}
+
+			foldIcon := ""
+			if group.Folded {
+				foldIcon = STYLE_Folded
+			}
+
+			writeLine(v, group, nil, "\n", selector, group.Name, "(", len(group.Tasks.Items), ")", noteIcon, foldIcon, projectBadges(group))
+
+			if group.Folded {
+				continue
+			}
+
+			writeLine(v, group, nil, strings.Repeat(STYLE_Boldline, maxX-2))
+
+			if (group.Notes != "") && (showNotes) {
+				writeLine(v, group, nil, "\x1b[2m"+group.Notes+"\x1b[0m")
+				writeLine(v, group, nil, strings.Repeat(STYLE_Thinline, maxX-2))
+
+			}
+
+			for _, task := range group.Tasks.Items {
+				if (!hidedone || !task.Done()) && matchesFilter(task) {
+					noteIcon := ""
+					if !showNotes && task.Notes != "" {
+						noteIcon = STYLE_HasNotes
+					}
+
+					checked := glyph(task.Status)
+					name := task.Name
+					if selected[task] {
+						name = STYLE_Selected + name + "\x1b[0m"
+					}
+					name = decoratedTask(task, name)
+					if (task == group.Tasks.Selected) && (group == doc.Projects.Selected) {
+
+						writeLine(v, group, task, STYLE_LineSelector, checked, task.Tag, name, noteIcon)
+					} else {
+						writeLine(v, group, task, " ", checked, task.Tag, name, noteIcon)
+					}
+
+					if task.Notes != "" && showNotes {
+
+						writeLine(v, group, task, "\x1b[2m"+task.Notes+"\x1b[0m")
+					}
+				}
+
+			}
+		}
+		scrollToSelected(v)
+	}
+	redrawDetails(g)
+	if v, e := g.View("footer"); e == nil {
+		//this needs more thought
+		v.Clear()
+		dirtyStr := " "
+		if doc.Dirty {
+			dirtyStr = "Dirty"
+		}
+
+		hidedoneStr := " "
+		if hidedone {
+			hidedoneStr = "Hide Done"
+		}
+
+		deleteStr := " "
+		if pendingDelete {
+			deleteStr = "Del"
+		}
+
+		selectStr := " "
+		if visualAnchor != nil {
+			selectStr = fmt.Sprintf("Visual %d", len(selected))
+		} else if len(selected) > 0 {
+			selectStr = fmt.Sprintf("%d marked", len(selected))
+		}
+
+		filterStr := " "
+		if filter != "" {
+			filterStr = "Filter: " + filter
+		}
+
+		viewStr := " "
+		if board {
+			viewStr = "Board " + boardBy
+		} else if outline {
+			viewStr = "Outline"
+		} else if focus {
+			viewStr = "Focus"
+		}
+
+		if unsaved := unsavedTabs(); len(docs) > 1 && len(unsaved) > 0 {
+			dirtyStr = "Unsaved: " + strings.Join(unsaved, " ")
+		}
+
+		fmt.Fprintln(v, state, viewStr, dirtyStr, hidedoneStr, deleteStr, countString(), selectStr, fmt.Sprintf("%d/%d", doneCount, taskCount), filterStr)
+		if statusMsg != "" {
+			fmt.Fprintln(v, statusMsg)
+		}
+	}
+
+}
+
+func editView(g *gocui.Gui, cv *gocui.View) error {
+	pendingDelete = false
+
+	var title string
+	var val string
+
+	switch state {
+	case State_Task:
+		if doc.Projects.Selected == nil {
+			return nil
+		}
+		title = "Edit Task for " + doc.Projects.Selected.Name
+		val = doc.Projects.Selected.Tasks.Selected.Name
+	case State_Project:
+		title = "Edit Project Name"
+		val = doc.Projects.Selected.Name
+	}
+
+	return showInput(g, "edit", title, val)
+}
+
+func addView(g *gocui.Gui, cv *gocui.View) error {
+	pendingDelete = false
+
+	var title string
+	switch state {
+	case State_Task:
+		if doc.Projects.Selected == nil {
+			return nil
+		}
+		title = "New Task for " + doc.Projects.Selected.Name
+	case State_Project:
+		title = "New Project"
+	}
+
+	return showInput(g, "add", title, "")
+}
+
+func showInput(g *gocui.Gui, cmdname string, title string, val string) error {
+	maxX, maxY := g.Size()
+	iv, err := g.SetView(cmdname, 3, maxY/2, maxX-3, maxY/2+2, 0)
+
+	if err != nil {
+		if !gocui.IsUnknownView(err) {
+			return err
+		}
+
+		iv.Title = title
+		iv.TitleColor = gocui.ColorYellow
+		iv.FrameColor = gocui.ColorRed
+		iv.FrameRunes = []rune{'═', '║', '╔', '╗', '╚', '╝', '╠', '╣', '╦', '╩', '╬'}
+		iv.Editable = true
+		g.Cursor = true
+		fmt.Fprint(iv, val)
+		iv.SetCursorX(len(val))
+		iv.TextArea.TypeString(val)
+
+		if _, err := g.SetCurrentView(cmdname); err != nil {
+			return err
+		}
+		g.SetKeybinding(cmdname, gocui.KeyEnter, gocui.ModNone, copyInput)
+		g.SetKeybinding(cmdname, gocui.KeyEsc, gocui.ModNone, closeInput)
+	}
+
+	return nil
+}
+
+func closeInput(g *gocui.Gui, iv *gocui.View) error {
+	var err error
+	var ov *gocui.View
+	ov, _ = g.View(viewname)
+
+	iv.Clear()
+	g.Cursor = false
+	g.DeleteViewKeybindings(iv.Name())
+	if err = g.DeleteView(iv.Name()); err != nil {
+		return err
+	}
+	// Set the view back.
+	if _, err = g.SetCurrentView(ov.Name()); err != nil {
+		return err
+	}
+
+	redraw(g)
+	return err
+}
+
+func copyInput(g *gocui.Gui, iv *gocui.View) error {
+	iv.Rewind()
+
+	if iv.Buffer() == "" {
+		return closeInput(g, iv)
+	}
+
+	switch iv.Name() {
+	case "add":
+		lastChange = "add " + iv.Buffer()
+		addItem(iv.Buffer())
+
+	case "edit":
+		lastChange = "rename " + iv.Buffer()
+		renameItem(iv.Buffer())
+	}
+
+	markDirty()
+	return closeInput(g, iv)
+}
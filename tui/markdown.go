@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiCode      = "\x1b[36m"
+)
+
+var mdBullet = regexp.MustCompile(`^(\s*)[-*+] `)
+
+// renderMarkdown turns the common inline markdown of notes into terminal
+// colors, it is meant for reading and does not try to be complete.
+func renderMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			lines[i] = ansiBold + strings.TrimSpace(strings.TrimLeft(line, "#")) + ansiReset
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			lines[i] = ansiDim + "│ " + strings.TrimSpace(strings.TrimPrefix(line, ">")) + ansiReset
+			continue
+		}
+
+		lines[i] = renderInline(mdBullet.ReplaceAllString(line, "$1• "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderInline colors the pieces mdtodo.ParseInline finds
+func renderInline(line string) string {
+	var sb strings.Builder
+	for _, in := range mdtodo.ParseInline(line) {
+		switch in.Kind {
+		case mdtodo.InlineBold:
+			sb.WriteString(ansiBold + in.Text + ansiReset)
+		case mdtodo.InlineItalic:
+			sb.WriteString(ansiItalic + in.Text + ansiReset)
+		case mdtodo.InlineCode:
+			sb.WriteString(ansiCode + in.Text + ansiReset)
+		case mdtodo.InlineLink:
+			sb.WriteString(ansiUnderline + in.Text + ansiReset + ansiDim + " (" + in.Link + ")" + ansiReset)
+		default:
+			sb.WriteString(in.Text)
+		}
+	}
+	return sb.String()
+}
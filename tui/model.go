@@ -0,0 +1,20 @@
+package tui
+
+import "github.com/darkaxi0m/mdtodo"
+
+// the todo model lives in the mdtodo package, the tui only draws and edits it
+type (
+	Task     = mdtodo.Task
+	Project  = mdtodo.Project
+	Projects = mdtodo.Projects
+	Status   = mdtodo.Status
+	TaskLess = mdtodo.TaskLess
+)
+
+const (
+	StatusOpen      = mdtodo.StatusOpen
+	StatusDone      = mdtodo.StatusDone
+	StatusDoing     = mdtodo.StatusDoing
+	StatusCancelled = mdtodo.StatusCancelled
+	StatusDeferred  = mdtodo.StatusDeferred
+)
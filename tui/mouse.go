@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jesseduffield/gocui"
+)
+
+const (
+	// the checkbox is drawn after the selector and a space
+	checkboxColumn = 2
+	doubleClick    = 400 * time.Millisecond
+	wheelLines     = 3
+)
+
+// screenLine is what a line of the todo view shows, task is nil for the
+// lines of a project header.
+type screenLine struct {
+	project *Project
+	task    *Task
+}
+
+var (
+	// built by redraw, one entry per line of the todo view
+	screenLines []screenLine
+	// line of the selected item, used to keep it scrolled into view
+	selectedLine     = -1
+	lastSelectedLine = -1
+
+	lastClick     time.Time
+	lastClickLine = -1
+	dragging      = false
+)
+
+// writeLine prints to the todo view and remembers which item every printed
+// line belongs to.
+func writeLine(w io.Writer, p *Project, t *Task, a ...interface{}) {
+	s := fmt.Sprintln(a...)
+	for i := 0; i < strings.Count(s, "\n"); i++ {
+		screenLines = append(screenLines, screenLine{p, t})
+	}
+
+	if p == doc.Projects.Selected && (t == nil && (state == State_Project || p.Folded || outline) || t != nil && t == p.Tasks.Selected && state == State_Task) {
+		selectedLine = len(screenLines) - 1
+	}
+	fmt.Fprint(w, s)
+}
+
+// scrollToSelected keeps the selection on screen when it moved, but leaves
+// the view alone otherwise so the wheel can scroll freely.
+func scrollToSelected(v *gocui.View) {
+	if selectedLine == lastSelectedLine || selectedLine < 0 {
+		return
+	}
+	lastSelectedLine = selectedLine
+
+	height := v.InnerHeight()
+	oy := v.OriginY()
+	if selectedLine < oy {
+		v.SetOriginY(selectedLine)
+	} else if selectedLine >= oy+height {
+		v.SetOriginY(selectedLine - height + 1)
+	}
+}
+
+func lineAt(v *gocui.View) (screenLine, int, bool) {
+	_, cy := v.Cursor()
+	y := v.OriginY() + cy
+	if y < 0 || y >= len(screenLines) {
+		return screenLine{}, y, false
+	}
+	return screenLines[y], y, true
+}
+
+// selectLine moves the selection to the item on the line
+func selectLine(line screenLine) {
+	doc.Projects.Selected = line.project
+	if line.task != nil {
+		line.project.Tasks.Selected = line.task
+		state = State_Task
+	} else if !outline {
+		state = State_Project
+	}
+}
+
+//---------Mouse handlers-----------------------------
+
+func mouseClick(g *gocui.Gui, v *gocui.View) error {
+	// a release the terminal never sent still ends the previous drag
+	mouseRelease(g, v)
+	line, y, ok := lineAt(v)
+	if !ok || line.project == nil {
+		return nil
+	}
+	pendingDelete = false
+	selectLine(line)
+
+	now := time.Now()
+	double := y == lastClickLine && now.Sub(lastClick) < doubleClick
+	lastClick, lastClickLine = now, y
+
+	cx, _ := v.Cursor()
+	x := v.OriginX() + cx
+	switch {
+	case double && outline:
+		return zoomProject(g, v)
+	case double:
+		lastClickLine = -1
+		return editView(g, v)
+	case line.task != nil && (x == checkboxColumn || x == checkboxColumn+1):
+		taskRef{line.project, line.task}.setStatus(nextStatus(line.task.Status))
+		markDirty()
+	}
+
+	redraw(g)
+	return nil
+}
+
+// mouseDrag moves the grabbed item towards the line under the pointer one
+// step at a time, the same way swapup and swapdown do.
+func mouseDrag(g *gocui.Gui, v *gocui.View) error {
+	line, _, ok := lineAt(v)
+	if !ok || line.project == nil || doc.Projects.Selected == nil {
+		return nil
+	}
+	if !dragging {
+		dragging = true
+		beginBatch()
+	}
+
+	switch state {
+	case State_Task:
+		if line.project != doc.Projects.Selected || line.task == nil {
+			break
+		}
+		for {
+			from, found := doc.Projects.Selected.Tasks.FindIndex(doc.Projects.Selected.Tasks.Selected)
+			to, _ := doc.Projects.Selected.Tasks.FindIndex(line.task)
+			if !found || from == to {
+				break
+			}
+			dir := 1
+			if to < from {
+				dir = -1
+			}
+			if doc.Projects.Selected.MoveSelected(dir, hidedone) == nil {
+				break
+			}
+			markDirty()
+			if now, _ := doc.Projects.Selected.Tasks.FindIndex(doc.Projects.Selected.Tasks.Selected); now == from {
+				break
+			}
+		}
+	case State_Project:
+		for {
+			from, found := doc.Projects.FindIndex(doc.Projects.Selected)
+			to, _ := doc.Projects.FindIndex(line.project)
+			if !found || from == to {
+				break
+			}
+			dir := 1
+			if to < from {
+				dir = -1
+			}
+			doc.Projects.MoveSelected(dir)
+			markDirty()
+		}
+	}
+
+	redraw(g)
+	return nil
+}
+
+func mouseRelease(g *gocui.Gui, v *gocui.View) error {
+	if dragging {
+		dragging = false
+		endBatch()
+	}
+	return nil
+}
+
+func mouseWheel(lines int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		y := v.OriginY() + lines
+		if limit := len(screenLines) - v.InnerHeight(); y > limit {
+			y = limit
+		}
+		if y < 0 {
+			y = 0
+		}
+		return v.SetOriginY(y)
+	}
+}
+
+func mouseBinding(g *gocui.Gui) {
+	g.SetKeybinding(viewname, gocui.MouseLeft, gocui.ModNone, mouseClick)
+	g.SetKeybinding(viewname, gocui.MouseLeft, gocui.ModMotion, mouseDrag)
+	// the button may come up over any view, a drag must end all the same
+	g.SetKeybinding("", gocui.MouseRelease, gocui.ModNone, mouseRelease)
+	g.SetKeybinding(viewname, gocui.MouseWheelUp, gocui.ModNone, mouseWheel(-wheelLines))
+	g.SetKeybinding(viewname, gocui.MouseWheelDown, gocui.ModNone, mouseWheel(wheelLines))
+}
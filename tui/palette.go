@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jesseduffield/gocui"
+)
+
+const (
+	cmdViewName        = "cmdline"
+	completionViewName = "completions"
+	maxCompletions     = 8
+)
+
+// completion state of the command line, Tab cycles through candidates
+var (
+	completions     []string
+	completionIndex = -1
+	completingArg   = false
+)
+
+func showCommandLine(g *gocui.Gui, cv *gocui.View) error {
+	pendingDelete = false
+	statusMsg = ""
+
+	maxX, maxY := g.Size()
+	iv, err := g.SetView(cmdViewName, 0, maxY-3, maxX-1, maxY-1, 0)
+	if err == nil {
+		return nil // already open
+	}
+	if !gocui.IsUnknownView(err) {
+		return err
+	}
+
+	iv.Title = ":"
+	iv.TitleColor = gocui.ColorYellow
+	iv.FrameColor = gocui.ColorRed
+	iv.Editable = true
+	iv.Editor = gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) bool {
+		handled := gocui.SimpleEditor(v, key, ch, mod)
+		if handled {
+			completionIndex = -1
+			updateCompletions(g, v)
+		}
+		return handled
+	})
+	g.Cursor = true
+
+	if _, err := g.SetCurrentView(cmdViewName); err != nil {
+		return err
+	}
+	g.SetKeybinding(cmdViewName, gocui.KeyEnter, gocui.ModNone, runCommandInput)
+	g.SetKeybinding(cmdViewName, gocui.KeyEsc, gocui.ModNone, closeCommandLine)
+	g.SetKeybinding(cmdViewName, gocui.KeyTab, gocui.ModNone, completeCommandInput)
+
+	updateCompletions(g, iv)
+	return nil
+}
+
+// layoutCommandLine keeps the command line over the footer when the terminal
+// is resized, it does nothing when the command line is closed.
+func layoutCommandLine(g *gocui.Gui) error {
+	if _, err := g.View(cmdViewName); err != nil {
+		return nil
+	}
+
+	maxX, maxY := g.Size()
+	iv, err := g.SetView(cmdViewName, 0, maxY-3, maxX-1, maxY-1, 0)
+	if err != nil {
+		return err
+	}
+	updateCompletions(g, iv)
+	return nil
+}
+
+// splitCommandInput splits the input into the command and the argument text
+func splitCommandInput(input string) (string, string, bool) {
+	input = strings.TrimLeft(input, " ")
+	name, arg, hasArg := strings.Cut(input, " ")
+	return name, strings.TrimLeft(arg, " "), hasArg
+}
+
+// candidates returns the completions for the command name, or the argument
+// once the name is followed by a space.
+func candidates(input string) []string {
+	name, arg, hasArg := splitCommandInput(input)
+	if !hasArg {
+		return fuzzyFilter(name, commandNames())
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil || cmd.Complete == nil {
+		return nil
+	}
+	return fuzzyFilter(arg, cmd.Complete())
+}
+
+func updateCompletions(g *gocui.Gui, iv *gocui.View) {
+	if completionIndex < 0 {
+		_, _, completingArg = splitCommandInput(iv.TextArea.GetContent())
+		completions = candidates(iv.TextArea.GetContent())
+	}
+
+	if len(completions) == 0 {
+		g.DeleteView(completionViewName)
+		return
+	}
+
+	shown := completions
+	if len(shown) > maxCompletions {
+		shown = shown[:maxCompletions]
+	}
+
+	maxX, maxY := g.Size()
+	cv, err := g.SetView(completionViewName, 0, maxY-4-len(shown)-1, maxX/2, maxY-3, 0)
+	if err != nil && !gocui.IsUnknownView(err) {
+		return
+	}
+	cv.Frame = true
+	cv.FrameColor = gocui.ColorRed
+	cv.Clear()
+
+	name, _, _ := splitCommandInput(iv.TextArea.GetContent())
+	cmd := findCommand(name)
+	for i, c := range shown {
+		marker := " "
+		if i == completionIndex || (completionIndex < 0 && i == 0) {
+			marker = STYLE_LineSelector
+		}
+		desc := ""
+		if !completingArg {
+			if cmd := findCommand(c); cmd != nil {
+				desc = "\x1b[2m" + cmd.Desc + "\x1b[0m"
+			}
+		} else if cmd != nil && i == 0 && cmd.Usage != "" {
+			desc = "\x1b[2m" + cmd.Usage + "\x1b[0m"
+		}
+		fmt.Fprintln(cv, marker, c, desc)
+	}
+	g.SetViewOnTop(completionViewName)
+}
+
+// completeCommandInput replaces the word being completed with the next candidate
+func completeCommandInput(g *gocui.Gui, iv *gocui.View) error {
+	if len(completions) == 0 {
+		return nil
+	}
+	completionIndex = (completionIndex + 1) % len(completions)
+
+	name, _, _ := splitCommandInput(iv.TextArea.GetContent())
+	line := completions[completionIndex] + " "
+	if completingArg {
+		line = name + " " + completions[completionIndex]
+	}
+
+	iv.TextArea.Clear()
+	iv.TextArea.TypeString(line)
+	iv.RenderTextArea()
+	updateCompletions(g, iv)
+	return nil
+}
+
+func runCommandInput(g *gocui.Gui, iv *gocui.View) error {
+	line := strings.TrimSpace(iv.TextArea.GetContent())
+	if err := closeCommandLine(g, iv); err != nil {
+		return err
+	}
+	return runCounted(g, line, 1)
+}
+
+func closeCommandLine(g *gocui.Gui, iv *gocui.View) error {
+	completions = nil
+	completionIndex = -1
+	g.Cursor = false
+	g.DeleteViewKeybindings(cmdViewName)
+	g.DeleteView(completionViewName)
+	if err := g.DeleteView(cmdViewName); err != nil {
+		return err
+	}
+	if _, err := g.SetCurrentView(viewname); err != nil {
+		return err
+	}
+	redraw(g)
+	return nil
+}
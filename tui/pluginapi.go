@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// pluginMethods are what plugins may call while mdtodo waits for them. The
+// answers use the JSON export schema, see mdtodo.JSONExport, and changes go
+// through the document so the done log, hooks and undo see them.
+var pluginMethods = map[string]func(p *plugin, params json.RawMessage) (any, error){
+	"getDocument":   apiGetDocument,
+	"getSelection":  apiGetSelection,
+	"addProject":    apiAddProject,
+	"editProject":   apiEditProject,
+	"deleteProject": apiDeleteProject,
+	"addTask":       apiAddTask,
+	"editTask":      apiEditTask,
+	"deleteTask":    apiDeleteTask,
+	"showMessage":   apiShowMessage,
+}
+
+// pluginSelection is where the cursor is, Marked holds the tasks marked or in
+// the visual range
+type pluginSelection struct {
+	Mode    string   `json:"mode"`
+	File    string   `json:"file"`
+	Project string   `json:"project,omitempty"`
+	Task    string   `json:"task,omitempty"`
+	Marked  []string `json:"marked"`
+}
+
+func currentSelection() pluginSelection {
+	sel := pluginSelection{Mode: "task", File: doc.Filename, Marked: []string{}}
+	if state == State_Project {
+		sel.Mode = "project"
+	}
+	ids := doc.Projects.TaskIDs()
+	if p := doc.Projects.Selected; p != nil {
+		sel.Project = mdtodo.ProjectID(p)
+		if p.Tasks.Selected != nil {
+			sel.Task = ids[p.Tasks.Selected]
+		}
+	}
+	for _, ref := range selectedTasks() {
+		sel.Marked = append(sel.Marked, ids[ref.task])
+	}
+	return sel
+}
+
+//---------Helpers-----------------------------
+
+func invalidParams(format string, a ...any) error {
+	return &rpcError{-32602, fmt.Sprintf(format, a...)}
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return invalidParams("missing params")
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+// change checks that the plugin may change the document now and notes that
+// it did
+func (p *plugin) change() error {
+	if p.access != accessChange {
+		return fmt.Errorf("the document can not be changed now")
+	}
+	p.changed = true
+	return nil
+}
+
+func apiProject(id string) (*Project, error) {
+	if p := doc.Projects.FindProject(id); p != nil {
+		return p, nil
+	}
+	return nil, &mdtodo.NotFoundError{What: "project", ID: id}
+}
+
+func apiTask(id string) (*Project, *Task, error) {
+	if p, t := doc.Projects.FindTask(id); t != nil {
+		return p, t, nil
+	}
+	return nil, nil, &mdtodo.NotFoundError{What: "task", ID: id}
+}
+
+// taskEdit checks the params, an unknown project is an error of the
+// document and anything else invalid params
+func taskEdit(args taskParams) (mdtodo.TaskEdit, error) {
+	edit, err := args.TaskEdit(doc.Projects)
+	var nf *mdtodo.NotFoundError
+	if err != nil && !errors.As(err, &nf) {
+		return edit, invalidParams("%v", err)
+	}
+	return edit, err
+}
+
+//---------Methods-----------------------------
+
+func apiGetDocument(p *plugin, params json.RawMessage) (any, error) {
+	return doc.Projects.ToJSON(), nil
+}
+
+func apiGetSelection(p *plugin, params json.RawMessage) (any, error) {
+	return currentSelection(), nil
+}
+
+type projectParams struct {
+	ID    string  `json:"id"`
+	Name  *string `json:"name"`
+	Notes *string `json:"notes"`
+}
+
+func apiAddProject(pl *plugin, params json.RawMessage) (any, error) {
+	var args projectParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	if args.Name == nil || strings.TrimSpace(*args.Name) == "" {
+		return nil, invalidParams("a project needs a name")
+	}
+	if err := pl.change(); err != nil {
+		return nil, err
+	}
+	p := doc.AddProject(strings.TrimSpace(*args.Name))
+	if args.Notes != nil {
+		doc.EditProject(p, nil, args.Notes)
+	}
+	return doc.Projects.ProjectJSON(p), nil
+}
+
+func apiEditProject(pl *plugin, params json.RawMessage) (any, error) {
+	var args projectParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	p, err := apiProject(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pl.change(); err != nil {
+		return nil, err
+	}
+	doc.EditProject(p, args.Name, args.Notes)
+	return doc.Projects.ProjectJSON(p), nil
+}
+
+func apiDeleteProject(pl *plugin, params json.RawMessage) (any, error) {
+	var args projectParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	p, err := apiProject(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pl.change(); err != nil {
+		return nil, err
+	}
+	doc.RemoveProject(p)
+	return nil, nil
+}
+
+type taskParams struct {
+	ID string `json:"id"`
+	mdtodo.JSONTaskEdit
+}
+
+// apiAddTask adds to the project given, or the selected one
+func apiAddTask(pl *plugin, params json.RawMessage) (any, error) {
+	var args taskParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	if args.Name == nil || strings.TrimSpace(*args.Name) == "" {
+		return nil, invalidParams("a task needs a name")
+	}
+	edit, err := taskEdit(args)
+	if err != nil {
+		return nil, err
+	}
+	p := doc.Projects.Selected
+	if edit.Project != nil {
+		p = edit.Project
+	}
+	if p == nil {
+		return nil, fmt.Errorf("no project to add to")
+	}
+	if err := pl.change(); err != nil {
+		return nil, err
+	}
+	t := doc.AddTask(p, *args.Name, edit)
+	return doc.Projects.TaskJSON(t), nil
+}
+
+// apiEditTask changes the fields given, a project id moves the task there
+// and an index moves it inside its project
+func apiEditTask(pl *plugin, params json.RawMessage) (any, error) {
+	var args taskParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	edit, err := taskEdit(args)
+	if err != nil {
+		return nil, err
+	}
+	p, t, err := apiTask(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pl.change(); err != nil {
+		return nil, err
+	}
+	doc.EditTask(p, t, edit)
+	return doc.Projects.TaskJSON(t), nil
+}
+
+func apiDeleteTask(pl *plugin, params json.RawMessage) (any, error) {
+	var args taskParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	p, t, err := apiTask(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pl.change(); err != nil {
+		return nil, err
+	}
+	doc.RemoveTask(p, t)
+	return nil, nil
+}
+
+// apiShowMessage shows a line in the footer
+func apiShowMessage(pl *plugin, params json.RawMessage) (any, error) {
+	var args struct {
+		Text string `json:"text"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	statusMsg = args.Text
+	return nil, nil
+}
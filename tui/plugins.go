@@ -0,0 +1,561 @@
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+// Plugins are executables in the plugins folder of the config directory.
+// They talk JSON-RPC 2.0 over stdin and stdout, one message per line:
+// mdtodo asks them to initialize, run their commands, decorate the tasks
+// and render exports, and while one of those calls runs they may call the
+// methods in pluginapi.go.
+
+// how long a plugin may take to answer, decorating runs after every change
+const (
+	pluginInitTimeout     = 5 * time.Second
+	pluginRunTimeout      = 30 * time.Second
+	pluginDecorateTimeout = 250 * time.Millisecond
+)
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// pluginCommand is a command a plugin adds to the command line and palette,
+// bound to Key in the todo view when it has one
+type pluginCommand struct {
+	Name   string `json:"name"`
+	Usage  string `json:"usage"`
+	Desc   string `json:"desc"`
+	Key    string `json:"key"`
+	Change bool   `json:"change"`
+}
+
+// pluginColumn is drawn before the task names, Width characters wide
+type pluginColumn struct {
+	Title string `json:"title"`
+	Width int    `json:"width"`
+}
+
+// pluginInfo is what a plugin answers to initialize
+type pluginInfo struct {
+	Name      string          `json:"name"`
+	Commands  []pluginCommand `json:"commands"`
+	Columns   []pluginColumn  `json:"columns"`
+	Decorate  bool            `json:"decorate"`
+	Renderers []string        `json:"renderers"`
+}
+
+type plugin struct {
+	pluginInfo
+	path  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	msgs  chan rpcMessage
+	id    int
+
+	// mu makes the calls one at a time, decorating runs in the background.
+	// access is what the plugin may do during the current call, changed
+	// notes a change made during it.
+	mu      sync.Mutex
+	access  pluginAccess
+	changed bool
+
+	// decorations is the last answer to decorate, only the ui goroutine
+	// uses it
+	decorations decorations
+}
+
+// pluginAccess is what a plugin may do with the document while mdtodo waits
+// for it
+type pluginAccess int
+
+const (
+	accessChange pluginAccess = iota // running a command
+	accessRead                       // initializing or rendering
+	accessNone                       // decorating, off the ui goroutine
+)
+
+var plugins []*plugin
+
+//---------Process-----------------------------
+
+func pluginDir() (string, error) {
+	return mdtodo.UserConfigPath("plugins")
+}
+
+// startPlugins starts the executables of the plugins folder and adds what
+// they provide. A plugin that fails is left out and named in the error.
+func startPlugins() error {
+	dir, err := pluginDir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var failed []string
+	keys := boundKeys()
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+		p, err := startPlugin(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			failed = append(failed, entry.Name()+": "+err.Error())
+			continue
+		}
+		plugins = append(plugins, p)
+		failed = append(failed, p.register(keys)...)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("plugins: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func startPlugin(path string) (*plugin, error) {
+	p := &plugin{path: path, msgs: make(chan rpcMessage, 16)}
+	p.Name = filepath.Base(path)
+	p.cmd = exec.Command(path)
+	p.cmd.Dir = filepath.Dir(path)
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	p.stdin = stdin
+	if err := p.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go p.read(stdout)
+
+	file := ""
+	if doc != nil {
+		file = doc.Filename
+	}
+	params := map[string]string{"version": mdtodo.ApplicationVersion, "file": file}
+	if err := p.call("initialize", params, &p.pluginInfo, pluginInitTimeout, accessRead); err != nil {
+		p.stop()
+		return nil, err
+	}
+	if p.Name == "" {
+		p.Name = filepath.Base(path)
+	}
+	return p, nil
+}
+
+// read hands the messages of the plugin to whoever waits for an answer,
+// lines that are not JSON are skipped
+func (p *plugin) read(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		p.msgs <- msg
+	}
+	close(p.msgs)
+}
+
+// stop closes stdin, which asks the plugin to exit, and kills it when it
+// does not
+func (p *plugin) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stdin.Close()
+	timer := time.AfterFunc(time.Second, func() { p.cmd.Process.Kill() })
+	for range p.msgs {
+	}
+	timer.Stop()
+	p.cmd.Wait()
+}
+
+func stopPlugins() {
+	for _, p := range plugins {
+		p.stop()
+	}
+	plugins = nil
+}
+
+//---------Messages-----------------------------
+
+func (p *plugin) send(msg rpcMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// call sends a request and waits for its answer. Requests of the plugin
+// that come in meanwhile are answered on the way, on the calling goroutine,
+// so they see and change the document like a key press does. access limits
+// what they may do.
+func (p *plugin) call(method string, params, result any, timeout time.Duration, access pluginAccess) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.access = access
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	p.id++
+	id := json.RawMessage(strconv.Itoa(p.id))
+	if err := p.send(rpcMessage{ID: id, Method: method, Params: data}); err != nil {
+		return fmt.Errorf("plugin %s: %v", p.Name, err)
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg, ok := <-p.msgs:
+			if !ok {
+				return fmt.Errorf("plugin %s exited", p.Name)
+			}
+			if msg.Method != "" {
+				p.serve(msg)
+				continue
+			}
+			// a late answer to a call that timed out
+			if string(msg.ID) != string(id) {
+				continue
+			}
+			if msg.Error != nil {
+				return fmt.Errorf("plugin %s: %s", p.Name, msg.Error.Message)
+			}
+			if result != nil && len(msg.Result) > 0 {
+				return json.Unmarshal(msg.Result, result)
+			}
+			return nil
+		case <-deadline:
+			return fmt.Errorf("plugin %s did not answer %s in time", p.Name, method)
+		}
+	}
+}
+
+// serve answers a request of the plugin, notifications get no answer
+func (p *plugin) serve(msg rpcMessage) {
+	var result any
+	var err error
+	if method, ok := pluginMethods[msg.Method]; !ok {
+		err = &rpcError{-32601, "unknown method " + msg.Method}
+	} else if p.access == accessNone {
+		err = fmt.Errorf("%s is only at hand while a command runs or an export renders", msg.Method)
+	} else if doc == nil {
+		err = fmt.Errorf("no document is open")
+	} else {
+		result, err = method(p, msg.Params)
+	}
+	if len(msg.ID) == 0 {
+		return
+	}
+
+	answer := rpcMessage{ID: msg.ID}
+	if err != nil {
+		rerr, ok := err.(*rpcError)
+		if !ok {
+			rerr = &rpcError{1, err.Error()}
+		}
+		answer.Error = rerr
+	} else if answer.Result, err = json.Marshal(result); err != nil {
+		answer.Result, answer.Error = nil, &rpcError{-32603, err.Error()}
+	}
+	p.send(answer)
+}
+
+//---------Commands and renderers-----------------------------
+
+// boundKeys maps the keys of the todo view and the global ones to what they
+// do, a plugin key must not take one of them
+func boundKeys() map[rune]string {
+	keys := fixedKeys()
+	if bindings == nil {
+		return keys
+	}
+	val := reflect.ValueOf(bindings).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		if key := val.Field(i).String(); key != "" {
+			keys[keyRune(key)] = val.Type().Field(i).Name
+		}
+	}
+	return keys
+}
+
+// register adds the commands and renderers of the plugin, it returns why
+// some could not be added. A command whose key is taken, see boundKeys, is
+// added without it, the keys it gets are added to keys.
+func (p *plugin) register(keys map[rune]string) []string {
+	var failed []string
+	for i := range p.Commands {
+		pc := &p.Commands[i]
+		if pc.Name == "" {
+			continue
+		}
+		if cmd := findCommand(pc.Name); cmd != nil {
+			failed = append(failed, fmt.Sprintf("%s: command %s is taken by %s", p.Name, pc.Name, cmd.Name))
+			pc.Key = ""
+			continue
+		}
+		switch key := []rune(pc.Key); {
+		case len(key) == 0:
+		case len(key) > 1:
+			failed = append(failed, fmt.Sprintf("%s: key %q of %s is not one character", p.Name, pc.Key, pc.Name))
+			pc.Key = ""
+		case keys[key[0]] != "":
+			failed = append(failed, fmt.Sprintf("%s: key %s of %s is bound to %s", p.Name, pc.Key, pc.Name, keys[key[0]]))
+			pc.Key = ""
+		default:
+			keys[key[0]] = pc.Name
+		}
+		desc := pc.Desc
+		if desc == "" {
+			desc = "Run " + pc.Name + " of the " + p.Name + " plugin"
+		}
+		commands = append(commands, &Command{Name: pc.Name, Usage: pc.Usage, Desc: desc, Change: pc.Change, Run: p.runCommand(pc.Name)})
+	}
+	for _, format := range p.Renderers {
+		if _, ok := mdtodo.LookupRenderer(format); ok {
+			failed = append(failed, fmt.Sprintf("%s: format %s exists", p.Name, format))
+			continue
+		}
+		mdtodo.RegisterRenderer(format, p.renderer(format))
+	}
+	return failed
+}
+
+// runCommand asks the plugin to run one of its commands, with the selection
+// at hand. Changes it makes through the api are one undo step.
+func (p *plugin) runCommand(name string) func(*gocui.Gui, []string) error {
+	return func(g *gocui.Gui, args []string) error {
+		p.changed = false
+		params := map[string]any{"command": name, "args": args, "selection": currentSelection()}
+		var result struct {
+			Message string `json:"message"`
+		}
+		err := p.call("run", params, &result, pluginRunTimeout, accessChange)
+		if p.changed {
+			markDirty()
+		}
+		if err != nil {
+			return err
+		}
+		if result.Message != "" {
+			statusMsg = result.Message
+		}
+		return nil
+	}
+}
+
+func (p *plugin) renderer(format string) mdtodo.Renderer {
+	return mdtodo.RendererFunc(func(w io.Writer, ps mdtodo.Projects) error {
+		var text string
+		params := map[string]any{"format": format, "document": ps.ToJSON()}
+		if err := p.call("render", params, &text, pluginRunTimeout, accessRead); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, text)
+		return err
+	})
+}
+
+// bindPlugins binds the keys of plugin commands in the todo view, register
+// dropped the ones that are taken
+func bindPlugins(g *gocui.Gui) {
+	for _, p := range plugins {
+		for _, pc := range p.Commands {
+			if pc.Key != "" {
+				g.SetKeybinding(viewname, []rune(pc.Key)[0], gocui.ModNone, bindCommand(pc.Name))
+			}
+		}
+	}
+}
+
+//---------Decorations-----------------------------
+
+// decoration is what plugins add to a task or project line
+type decoration struct {
+	Badge   string   `json:"badge"`
+	Columns []string `json:"columns"`
+}
+
+// decorations is the answer of a plugin to decorate, by task and project id
+type decorations struct {
+	Tasks    map[string]decoration `json:"tasks"`
+	Projects map[string]decoration `json:"projects"`
+}
+
+var (
+	// decorating is set while the plugins decorate, redecorate when the
+	// document changed meanwhile
+	decorating, redecorate bool
+	// decorateQueued is set when a refresh waits for the change to finish
+	decorateQueued bool
+)
+
+// decorationsOutdated is a Document listener. The refresh waits for the
+// ui goroutine to finish the change, one key press may notify many times.
+func decorationsOutdated(e mdtodo.Event) {
+	if e.Kind != mdtodo.EventSaved {
+		refreshDecorations()
+	}
+}
+
+func refreshDecorations() {
+	if gui == nil || decorateQueued {
+		return
+	}
+	decorateQueued = true
+	gui.Update(func(*gocui.Gui) error {
+		decorateQueued = false
+		decorate()
+		return nil
+	})
+}
+
+// decorate asks the plugins that decorate for the badges and columns of the
+// current document in the background, drawing uses the last answers until
+// the new ones are in. A plugin that is too slow is not asked again.
+func decorate() {
+	if decorating {
+		redecorate = true
+		return
+	}
+	var asked []*plugin
+	for _, p := range plugins {
+		if p.Decorate {
+			asked = append(asked, p)
+		}
+	}
+	if gui == nil || doc == nil || len(asked) == 0 {
+		return
+	}
+
+	decorating = true
+	params := map[string]any{"document": doc.Projects.ToJSON()}
+	g := gui
+	go func() {
+		answers := make([]decorations, len(asked))
+		errs := make([]error, len(asked))
+		for i, p := range asked {
+			errs[i] = p.call("decorate", params, &answers[i], pluginDecorateTimeout, accessNone)
+		}
+		g.Update(func(g *gocui.Gui) error {
+			decorating = false
+			for i, p := range asked {
+				if errs[i] != nil {
+					p.Decorate = false
+					statusMsg = errs[i].Error()
+					continue
+				}
+				p.decorations = answers[i]
+			}
+			if redecorate {
+				redecorate = false
+				decorate()
+			}
+			redraw(g)
+			return nil
+		})
+	}()
+}
+
+// fitColumns pads or cuts the cells to the widths of the columns, so they
+// line up
+func fitColumns(cells []string, columns []pluginColumn) []string {
+	fitted := make([]string, len(columns))
+	for i, c := range columns {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		if r := []rune(cell); len(r) > c.Width {
+			cell = string(r[:c.Width])
+		}
+		fitted[i] = cell + strings.Repeat(" ", c.Width-len([]rune(cell)))
+	}
+	return fitted
+}
+
+// decoratedTask puts the plugin columns before the name and the badges after
+func decoratedTask(t *Task, name string) string {
+	var before, after []string
+	for _, p := range plugins {
+		if !p.Decorate {
+			continue
+		}
+		d := p.decorations.Tasks[t.ID]
+		before = append(before, fitColumns(d.Columns, p.Columns)...)
+		if d.Badge != "" {
+			after = append(after, d.Badge)
+		}
+	}
+	return strings.Join(append(append(before, name), after...), " ")
+}
+
+func projectBadges(p *Project) string {
+	var badges []string
+	for _, pl := range plugins {
+		d := pl.decorations.Projects[p.ID]
+		if pl.Decorate && d.Badge != "" {
+			badges = append(badges, d.Badge)
+		}
+	}
+	return strings.Join(badges, " ")
+}
+
+func pluginNames() []string {
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cmdPlugins(g *gocui.Gui, args []string) error {
+	if len(plugins) == 0 {
+		dir, _ := pluginDir()
+		return fmt.Errorf("no plugins in %s", dir)
+	}
+	return fmt.Errorf("plugins: %s", strings.Join(pluginNames(), " "))
+}
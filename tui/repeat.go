@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"strconv"
+
+	"github.com/jesseduffield/gocui"
+)
+
+var (
+	// count typed before a key, eg the 10 in 10J. 0 means none.
+	count = 0
+	// the last change made, replayed by the repeat key
+	lastChange      = ""
+	lastChangeCount = 1
+
+	batchDepth = 0
+	batchDirty = false
+)
+
+// beginBatch groups changes so they are saved and undone as one
+func beginBatch() {
+	if batchDepth == 0 {
+		// undo should bring the selection back to where the change started
+		doc.undoBase.project, doc.undoBase.task = selectionIndex()
+	}
+	batchDepth++
+}
+
+func endBatch() {
+	batchDepth--
+	if batchDepth == 0 && batchDirty {
+		batchDirty = false
+		markDirty()
+	}
+}
+
+// countDigit is bound to the digit keys of the todo view
+func countDigit(digit int) func(*gocui.Gui, *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		count = count*10 + digit
+		redraw(g)
+		return nil
+	}
+}
+
+// takeCount returns the pending count, 1 when none was typed, and clears it
+func takeCount() int {
+	n := count
+	count = 0
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// runCounted runs the command line n times as a single change
+func runCounted(g *gocui.Gui, line string, n int) error {
+	beginBatch()
+	defer endBatch()
+
+	for i := 0; i < n; i++ {
+		if err := runCommandLine(g, line); err != nil {
+			return err
+		}
+		if statusMsg != "" {
+			break
+		}
+	}
+
+	if cmd := findCommand(firstWord(line)); cmd != nil && cmd.Change {
+		lastChange = line
+		lastChangeCount = n
+	}
+	return nil
+}
+
+// repeatChange replays the last change on the current selection, a count
+// typed before the repeat key replaces the original count.
+func repeatChange(g *gocui.Gui, v *gocui.View) error {
+	n := lastChangeCount
+	if count > 0 {
+		n = takeCount()
+	}
+	if lastChange == "" {
+		return nil
+	}
+	return runCounted(g, lastChange, n)
+}
+
+func countString() string {
+	if count == 0 {
+		return " "
+	}
+	return strconv.Itoa(count)
+}
@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"github.com/jesseduffield/gocui"
+)
+
+// taskRef is a task together with the project it lives in
+type taskRef struct {
+	project *Project
+	task    *Task
+}
+
+var (
+	// tasks toggled with the mark key
+	marked = map[*Task]bool{}
+	// start of the visual range, nil when not in visual mode
+	visualAnchor *Task
+)
+
+// isHidden reports if the task is currently not drawn
+func isHidden(t *Task) bool {
+	return (hidedone && t.Done()) || !matchesFilter(t)
+}
+
+// visibleTasks returns the drawn tasks across all projects, in file order
+func visibleTasks() []taskRef {
+	var refs []taskRef
+	for _, p := range doc.Projects.Items {
+		if p.Folded || (focus && p != doc.Projects.Selected) {
+			continue
+		}
+		for _, t := range p.Tasks.Items {
+			if !isHidden(t) {
+				refs = append(refs, taskRef{p, t})
+			}
+		}
+	}
+	return refs
+}
+
+// selectedTasks returns the marked tasks plus the visual range, in file
+// order. It is empty when nothing is selected, commands then act on the
+// single selected task as usual.
+func selectedTasks() []taskRef {
+	if len(marked) == 0 && visualAnchor == nil {
+		return nil
+	}
+
+	refs := visibleTasks()
+
+	// the range runs from the anchor to the selected task, in either direction
+	lo, hi := -1, -1
+	if visualAnchor != nil && doc.Projects.Selected != nil {
+		anchor, current := -1, -1
+		for i, ref := range refs {
+			if ref.task == visualAnchor {
+				anchor = i
+			}
+			if ref.task == doc.Projects.Selected.Tasks.Selected {
+				current = i
+			}
+		}
+		if anchor < 0 {
+			anchor = current
+		}
+		lo, hi = min(anchor, current), max(anchor, current)
+	}
+
+	var selected []taskRef
+	for i, ref := range refs {
+		if (lo >= 0 && i >= lo && i <= hi) || marked[ref.task] {
+			selected = append(selected, ref)
+		}
+	}
+	return selected
+}
+
+func clearSelection() {
+	marked = map[*Task]bool{}
+	visualAnchor = nil
+}
+
+//---------Keys-----------------------------
+
+func toggleVisual(g *gocui.Gui, v *gocui.View) error {
+	if visualAnchor != nil {
+		// leaving visual mode keeps the range as marks
+		for _, ref := range selectedTasks() {
+			marked[ref.task] = true
+		}
+		visualAnchor = nil
+	} else if doc.Projects.Selected != nil && doc.Projects.Selected.Tasks.Selected != nil {
+		state = State_Task
+		visualAnchor = doc.Projects.Selected.Tasks.Selected
+	}
+	redraw(g)
+	return nil
+}
+
+func toggleMark(g *gocui.Gui, v *gocui.View) error {
+	if doc.Projects.Selected == nil || doc.Projects.Selected.Tasks.Selected == nil {
+		return nil
+	}
+	t := doc.Projects.Selected.Tasks.Selected
+	if marked[t] {
+		delete(marked, t)
+	} else {
+		marked[t] = true
+	}
+	redraw(g)
+	return nil
+}
+
+//---------Bulk operations-----------------------------
+
+// bulkToggle marks every task done, or undone if they all were done already
+func bulkToggle(refs []taskRef) {
+	allDone := true
+	for _, ref := range refs {
+		allDone = allDone && ref.task.Done()
+	}
+	status := StatusDone
+	if allDone {
+		status = StatusOpen
+	}
+	for _, ref := range refs {
+		ref.setStatus(status)
+	}
+}
+
+// bulkTag tags every task, or clears the tags if they all had one
+func bulkTag(refs []taskRef) {
+	allTagged := true
+	for _, ref := range refs {
+		allTagged = allTagged && ref.task.Tag != ""
+	}
+	for _, ref := range refs {
+		if allTagged {
+			ref.task.Tag = ""
+		} else {
+			ref.task.Tag = "🔥"
+		}
+	}
+}
+
+// removeTasks takes the tasks out of their projects
+func removeTasks(refs []taskRef) {
+	remove := map[*Project]map[*Task]bool{}
+	for _, ref := range refs {
+		if remove[ref.project] == nil {
+			remove[ref.project] = map[*Task]bool{}
+		}
+		remove[ref.project][ref.task] = true
+	}
+	for p, ts := range remove {
+		p.Tasks.RemoveAll(ts)
+	}
+}
+
+func bulkMove(refs []taskRef, target *Project) {
+	var moving []taskRef
+	for _, ref := range refs {
+		if ref.project != target {
+			moving = append(moving, ref)
+		}
+	}
+	removeTasks(moving)
+	for _, ref := range moving {
+		target.Tasks.Items = append(target.Tasks.Items, ref.task)
+	}
+}
+
+// bulkShift moves the selected tasks one visible place up (-1) or down (+1)
+// inside their projects, a selected block moves as a whole.
+func bulkShift(refs []taskRef, dir int) {
+	selected := map[*Task]bool{}
+	for _, ref := range refs {
+		selected[ref.task] = true
+	}
+
+	for _, p := range doc.Projects.Items {
+		items := p.Tasks.Items
+		start, end := 0, len(items)
+		if dir > 0 {
+			start, end = len(items)-1, -1
+		}
+		for i := start; i != end; i -= dir {
+			if !selected[items[i]] {
+				continue
+			}
+			target := i + dir
+			for target >= 0 && target < len(items) && isHidden(items[target]) {
+				target += dir
+			}
+			if target < 0 || target >= len(items) || selected[items[target]] {
+				continue
+			}
+
+			t := items[i]
+			for j := i; j != target; j += dir {
+				items[j] = items[j+dir]
+			}
+			items[target] = t
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// useDoc makes a document of the markdown the current one, with nothing
+// hidden, filtered or selected
+func useDoc(t *testing.T, md string) {
+	t.Helper()
+	ps, err := mdtodo.ReadFrom(strings.NewReader(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = &Document{Document: mdtodo.NewDocument("todo.md")}
+	doc.Projects = ps
+	hidedone, focus, filter = false, false, ""
+	clearSelection()
+}
+
+// taskNames lists the names of the tasks of the project
+func taskNames(p *Project) string {
+	var names []string
+	for _, t := range p.Tasks.Items {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestBulkShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		selected string
+		dir      int
+		hidedone bool
+		want     string
+	}{
+		{"one up", "b", -1, false, "b a c d"},
+		{"block down", "b c", +1, false, "a d b c"},
+		{"at the top", "a", -1, false, "a b c d"},
+		{"apart down", "a c", +1, false, "b a d c"},
+		{"block at the top", "a b", -1, false, "a b c d"},
+		{"over a hidden task", "c", -1, true, "c a b d"},
+	}
+	for _, tt := range tests {
+		useDoc(t, "## A\n- [ ] a\n- [x] b\n- [ ] c\n- [ ] d\n")
+		hidedone = tt.hidedone
+		p := doc.Projects.Items[0]
+		var refs []taskRef
+		for _, task := range p.Tasks.Items {
+			if strings.Contains(" "+tt.selected+" ", " "+task.Name+" ") {
+				refs = append(refs, taskRef{p, task})
+			}
+		}
+		bulkShift(refs, tt.dir)
+		if got := taskNames(p); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/darkaxi0m/mdtodo/server"
+	"github.com/jesseduffield/gocui"
+)
+
+const defaultServeAddr = "127.0.0.1:8080"
+
+// serving is the address the tui serves a file on, empty when it does not
+var serving string
+
+// runServe serves the todo file over HTTP, `mdtodo serve --addr 127.0.0.1:8080`
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", defaultServeAddr, "address to listen on")
+	token := flags.String("token", "", "token the API asks for, made up when not on loopback")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	d, err := mdtodo.Open(fileArg(flags))
+	if err != nil {
+		return err
+	}
+	d.OnEvent(logDone(func(err error) { fmt.Fprintln(os.Stderr, "done log:", err) }))
+	d.OnEvent(mdtodo.RunHooks)
+	if c := newGitCommitter(); c != nil {
+		d.OnEvent(c.OnEvent)
+	}
+
+	s := server.New(d, server.Options{
+		Save: func() error {
+			applySortPolicy(d.Projects)
+			return d.Save()
+		},
+		Next:  nextStatus,
+		Token: *token,
+	})
+	l, err := s.Listen(*addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", d.Filename, s.URL())
+	return s.Serve(l)
+}
+
+// serveTab serves the file of the current tab and the web ui next to the
+// tui. Requests run on the gui goroutine like key presses, so the tab and
+// the browser always show the same document.
+func serveTab(g *gocui.Gui, addr string) error {
+	if serving != "" {
+		return fmt.Errorf("already serving on http://%s", serving)
+	}
+	if globalMode() || doc.archiveOf != "" {
+		return fmt.Errorf("only a todo file can be served")
+	}
+
+	served := doc
+	s := server.New(served.Document, server.Options{
+		Do: func(f func() error) error {
+			done := make(chan error, 1)
+			g.Update(func(g *gocui.Gui) error {
+				current := doc
+				doc = served
+				err := f()
+				doc = current
+				redraw(g)
+				done <- err
+				return nil
+			})
+			return <-done
+		},
+		Save: func() error {
+			markDirty()
+			return nil
+		},
+		// unsaved changes in the tui win over the file
+		Reload: func() error {
+			if !served.Dirty {
+				openFile(served.Filename)
+			}
+			return nil
+		},
+		Next:  nextStatus,
+		Token: settings.ServeToken,
+	})
+
+	l, err := s.Listen(addr)
+	if err != nil {
+		return err
+	}
+	serving = l.Addr().String()
+	go s.Serve(l)
+	statusMsg = "serving " + served.name() + " on " + s.URL()
+	return nil
+}
+
+func cmdServe(g *gocui.Gui, args []string) error {
+	addr := strings.Join(args, " ")
+	if addr == "" {
+		addr = settings.Serve
+	}
+	if addr == "" {
+		addr = defaultServeAddr
+	}
+	return serveTab(g, addr)
+}
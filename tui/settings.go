@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// Settings holds the user preferences that are not key bindings
+type Settings struct {
+	ShowDetails     bool   `json:"ShowDetails"`
+	DetailPosition  string `json:"DetailPosition"` // right or bottom
+	DetailSize      int    `json:"DetailSize"`     // columns when right, lines when bottom
+	DetailMinWidth  int    `json:"DetailMinWidth"` // hide the details on narrower terminals
+	DetailMinHeight int    `json:"DetailMinHeight"`
+
+	// the checkbox states the toggle key goes through, " " "/" "x" "-" ">" or
+	// any other character
+	ToggleCycle []string `json:"ToggleCycle"`
+	// completed tasks are appended to this file, next to the todo file when
+	// relative, empty to not log them
+	DoneLog string `json:"DoneLog"`
+	// "file" archives into todo.archive.md, "section" into an ## Archive
+	// project of the todo file
+	Archive string `json:"Archive"`
+	// archive tasks done that many days ago when a file is opened, 0 is off
+	ArchiveAfter int `json:"ArchiveAfter"`
+	// sort policy applied on save, done tasks to the "bottom" or "top" and
+	// then by "priority", "due" or "name", empty keeps the order
+	SortDone string `json:"SortDone"`
+	SortBy   string `json:"SortBy"`
+	// `mdtodo global` shows the files named GlobalNames found in these folders
+	GlobalRoots []string `json:"GlobalRoots"`
+	GlobalNames []string `json:"GlobalNames"`
+	// serve the todo file and the web ui on this address next to the tui,
+	// eg "127.0.0.1:8080", empty to not. Other than loopback the API needs
+	// ServeToken, a random one every time when it is empty.
+	Serve      string `json:"Serve"`
+	ServeToken string `json:"ServeToken"`
+	// commit the todo file to its git repository GitCommitDelay seconds
+	// after the last save, see mdtodo.GitCommitter. Global mode does not
+	// commit.
+	GitCommit      bool `json:"GitCommit"`
+	GitCommitDelay int  `json:"GitCommitDelay"`
+}
+
+var settings *Settings
+
+func defaultSettings() *Settings {
+	return &Settings{
+		ShowDetails:     false,
+		DetailPosition:  "right",
+		DetailSize:      40,
+		DetailMinWidth:  100,
+		DetailMinHeight: 30,
+		ToggleCycle:     []string{" ", "x"},
+		Archive:         "file",
+		GlobalNames:     []string{"todo.md"},
+		GitCommitDelay:  30,
+	}
+}
+
+func LoadSettingsWithDefaults(filename string) *Settings {
+	defaults := defaultSettings()
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return defaults
+	}
+	defer file.Close()
+
+	var loaded Settings
+	if err := json.NewDecoder(file).Decode(&loaded); err != nil {
+		fmt.Println("Error decoding settings file:", err)
+		return defaults
+	}
+
+	mergeNonEmptyFields(defaults, &loaded)
+	if err := defaults.checkSort(); err != nil {
+		addStatus(err.Error())
+	}
+	if err := defaults.checkCycle(); err != nil {
+		addStatus(err.Error())
+	}
+	return defaults
+}
+
+// checkCycle drops the states that are not one checkbox character, they
+// would be written as a line that reads back as notes
+func (s *Settings) checkCycle() error {
+	var cycle, bad []string
+	for _, c := range s.ToggleCycle {
+		if utf8.RuneCountInString(c) != 1 || c == "]" {
+			bad = append(bad, strconv.Quote(c))
+			continue
+		}
+		cycle = append(cycle, c)
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+	s.ToggleCycle = cycle
+	if len(cycle) == 0 {
+		s.ToggleCycle = defaultSettings().ToggleCycle
+	}
+	return fmt.Errorf("%s: ToggleCycle entries %s are not one checkbox character, ignored", SettingsConfig, strings.Join(bad, ", "))
+}
+
+// checkSort drops a sort policy it does not know, so a typo does not sort
+// differently than asked
+func (s *Settings) checkSort() error {
+	var errs []string
+	switch s.SortDone {
+	case "", "top", "bottom":
+	default:
+		errs = append(errs, fmt.Sprintf("SortDone %q is not top or bottom", s.SortDone))
+		s.SortDone = ""
+	}
+	if _, ok := mdtodo.SortKeys[s.SortBy]; s.SortBy != "" && !ok {
+		errs = append(errs, fmt.Sprintf("SortBy %q is not one of %s", s.SortBy, strings.Join(mdtodo.SortKeyNames(), ", ")))
+		s.SortBy = ""
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("config.json: %s, ignored", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func saveSettings(filename string, settings *Settings) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ") // Pretty-print JSON
+	return encoder.Encode(settings)
+}
+
+func LoadSettings() *Settings {
+	path, err := mdtodo.UserConfigPath(SettingsConfig)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		_ = saveSettings(path, defaultSettings())
+	}
+
+	return LoadSettingsWithDefaults(path)
+}
+
+// storeSettings writes the current settings back, used when they are changed
+// from inside mdtodo
+func storeSettings() error {
+	path, err := mdtodo.UserConfigPath(SettingsConfig)
+	if err != nil {
+		return err
+	}
+	return saveSettings(path, settings)
+}
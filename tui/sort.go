@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"github.com/darkaxi0m/mdtodo"
+)
+
+// sortPolicy orders the tasks the way the settings ask for when saving, done
+// tasks to the bottom or top and then by SortBy. It is nil when the order is
+// left alone.
+func sortPolicy() TaskLess {
+	by := mdtodo.SortKeys[settings.SortBy]
+	if settings.SortDone == "" && by == nil {
+		return nil
+	}
+	return func(a, b *Task) bool {
+		if settings.SortDone != "" && a.Done() != b.Done() {
+			return a.Done() == (settings.SortDone == "top")
+		}
+		if by != nil {
+			return by(a, b)
+		}
+		return false
+	}
+}
+
+// applySortPolicy sorts every project, selections point at tasks so they
+// stay on the same task
+func applySortPolicy(ps Projects) {
+	less := sortPolicy()
+	if less == nil {
+		return
+	}
+	for _, p := range ps.Items {
+		p.SortTasks(less)
+	}
+}
@@ -0,0 +1,28 @@
+package tui
+
+import (
+	"testing"
+)
+
+func TestSortPolicy(t *testing.T) {
+	tests := []struct {
+		sortDone, sortBy string
+		want             string
+	}{
+		{"", "", "c a d b ⏫"},
+		{"bottom", "", "c d a b ⏫"},
+		{"top", "", "a b ⏫ c d"},
+		{"", "name", "a b ⏫ c d"},
+		{"bottom", "name", "c d a b ⏫"},
+		{"top", "priority", "b ⏫ a c d"},
+	}
+	for _, tt := range tests {
+		useDoc(t, "## A\n- [ ] c\n- [x] a\n- [ ] d\n- [x] b ⏫\n")
+		settings = defaultSettings()
+		settings.SortDone, settings.SortBy = tt.sortDone, tt.sortBy
+		applySortPolicy(doc.Projects)
+		if got := taskNames(doc.Projects.Items[0]); got != tt.want {
+			t.Errorf("SortDone %q SortBy %q: got %q, want %q", tt.sortDone, tt.sortBy, got, tt.want)
+		}
+	}
+}
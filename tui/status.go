@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+// statusStyle is how redraw shows a state
+type statusStyle struct {
+	glyph string
+	color string
+	label string
+}
+
+var statusStyles = map[Status]statusStyle{
+	StatusOpen:      {STYLE_Checked, "", "open"},
+	StatusDone:      {STYLE_UnChecked, "\x1b[32m", "done"},
+	"X":             {STYLE_UnChecked, "\x1b[32m", "done"},
+	StatusDoing:     {STYLE_Doing, "\x1b[33m", "in progress"},
+	StatusCancelled: {STYLE_Cancelled, ansiDim, "cancelled"},
+	StatusDeferred:  {STYLE_Deferred, "\x1b[34m", "deferred"},
+}
+
+func styleOf(s Status) statusStyle {
+	if style, ok := statusStyles[s]; ok {
+		return style
+	}
+	return statusStyle{string(s), "\x1b[35m", "[" + string(s) + "]"}
+}
+
+// glyph is the colored checkbox drawn in front of the task
+func glyph(s Status) string {
+	style := styleOf(s)
+	if style.color == "" {
+		return style.glyph
+	}
+	return style.color + style.glyph + ansiReset
+}
+
+// nextStatus is the state the toggle key moves to. States outside of the
+// cycle go to its end when they are open and to its start when closed.
+func nextStatus(s Status) Status {
+	cycle := settings.ToggleCycle
+	if len(cycle) == 0 {
+		cycle = defaultSettings().ToggleCycle
+	}
+	if s == "X" {
+		s = StatusDone
+	}
+	for i, c := range cycle {
+		if Status(c) == s {
+			return Status(cycle[(i+1)%len(cycle)])
+		}
+	}
+	if s.Closed() {
+		return Status(cycle[0])
+	}
+	return Status(cycle[len(cycle)-1])
+}
+
+// cmdStatus sets a state by name, or any single character as is
+func cmdStatus(g *gocui.Gui, args []string) error {
+	status, ok := mdtodo.StatusNamed(strings.Join(args, " "))
+	if !ok {
+		return fmt.Errorf("usage: status open|done|doing|cancelled|deferred|<char>")
+	}
+
+	refs := selectedTasks()
+	if len(refs) == 0 && doc.Projects.Selected != nil && doc.Projects.Selected.Tasks.Selected != nil {
+		refs = []taskRef{{doc.Projects.Selected, doc.Projects.Selected.Tasks.Selected}}
+	}
+	for _, ref := range refs {
+		ref.setStatus(status)
+	}
+	if len(refs) > 0 {
+		clearSelection()
+		markDirty()
+	}
+	return nil
+}
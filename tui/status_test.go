@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNextStatus(t *testing.T) {
+	tests := []struct {
+		cycle []string
+		from  Status
+		want  Status
+	}{
+		{nil, StatusOpen, StatusDone},
+		{nil, StatusDone, StatusOpen},
+		{nil, "X", StatusOpen},
+		{nil, StatusDoing, StatusDone},
+		{nil, StatusCancelled, StatusOpen},
+		{[]string{" ", "/", "x"}, StatusOpen, StatusDoing},
+		{[]string{" ", "/", "x"}, StatusDoing, StatusDone},
+		{[]string{" ", "/", "x"}, StatusDone, StatusOpen},
+		{[]string{" ", "/", "x"}, "?", StatusDone},
+		{[]string{"/", "x"}, StatusCancelled, StatusDoing},
+	}
+	for _, tt := range tests {
+		settings = defaultSettings()
+		if tt.cycle != nil {
+			settings.ToggleCycle = tt.cycle
+		}
+		if got := nextStatus(tt.from); got != tt.want {
+			t.Errorf("nextStatus(%q) with %q = %q, want %q", tt.from, tt.cycle, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCycle(t *testing.T) {
+	tests := []struct {
+		cycle, want []string
+		err         bool
+	}{
+		{[]string{" ", "/", "x"}, []string{" ", "/", "x"}, false},
+		{[]string{" ", "ü"}, []string{" ", "ü"}, false},
+		{[]string{" ", "xx", "]", "", "x"}, []string{" ", "x"}, true},
+		{[]string{"]"}, []string{" ", "x"}, true},
+	}
+	for _, tt := range tests {
+		s := defaultSettings()
+		s.ToggleCycle = tt.cycle
+		err := s.checkCycle()
+		if (err != nil) != tt.err || strings.Join(s.ToggleCycle, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("checkCycle(%q) = %q, %v", tt.cycle, s.ToggleCycle, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/darkaxi0m/mdtodo"
+	"github.com/jesseduffield/gocui"
+)
+
+const maxUndo = 100
+
+// snapshot is the saved markdown plus where the selection was
+type snapshot struct {
+	content string
+	project int
+	task    int
+	files   []string // file of every project in global mode
+	// archive files written back when the snapshot is restored, so undo
+	// takes archived tasks out of the archive file again, or restored ones
+	// out of the todo file
+	archives map[string]string
+}
+
+func takeSnapshot() snapshot {
+	s := snapshot{content: doc.Projects.String()}
+	s.project, s.task = selectionIndex()
+	if globalMode() {
+		for _, p := range doc.Projects.Items {
+			s.files = append(s.files, p.File)
+		}
+	}
+	return s
+}
+
+// selectionIndex returns the index of the selected project and task, -1 if none
+func selectionIndex() (int, int) {
+	project, _ := doc.Projects.FindIndex(doc.Projects.Selected)
+	task := -1
+	if doc.Projects.Selected != nil {
+		task, _ = doc.Projects.Selected.Tasks.FindIndex(doc.Projects.Selected.Tasks.Selected)
+	}
+	return project, task
+}
+
+// resetUndo clears the history, used when a file is (re)loaded
+func resetUndo() {
+	doc.undoStack = nil
+	doc.redoStack = nil
+	doc.undoBase = takeSnapshot()
+	doc.archiveBefore, doc.archiveAfter = nil, nil
+}
+
+// recordUndo is called by markDirty after each change
+func recordUndo() {
+	previous := doc.undoBase
+	if doc.archiveBefore != nil {
+		previous.archives = doc.archiveBefore
+	}
+	doc.undoStack = append(doc.undoStack, previous)
+	if len(doc.undoStack) > maxUndo {
+		doc.undoStack = doc.undoStack[1:]
+	}
+	doc.redoStack = nil
+	doc.undoBase = takeSnapshot()
+	doc.undoBase.archives = doc.archiveAfter
+	doc.archiveBefore, doc.archiveAfter = nil, nil
+}
+
+// currentSnapshot is the state undo and redo leave, with the archive files
+// as the last change left them
+func currentSnapshot() snapshot {
+	s := takeSnapshot()
+	s.archives = doc.undoBase.archives
+	return s
+}
+
+// writeArchives puts the archive files back, an empty one was not there
+func writeArchives(archives map[string]string) {
+	for path, content := range archives {
+		var err error
+		if content == "" {
+			err = os.Remove(path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			statusMsg = err.Error()
+		}
+	}
+}
+
+func restoreSnapshot(s snapshot) {
+	folded := foldedNames()
+	doc.Projects, _ = mdtodo.ReadFrom(strings.NewReader(s.content))
+	for i, file := range s.files {
+		if i < len(doc.Projects.Items) {
+			doc.Projects.Items[i].File = file
+		}
+	}
+	applyFolds(folded)
+	doc.Projects.Selected = nil
+	if s.project >= 0 && s.project < len(doc.Projects.Items) {
+		doc.Projects.Selected = doc.Projects.Items[s.project]
+		doc.Projects.Selected.Tasks.Selected = nil
+		if s.task >= 0 && s.task < len(doc.Projects.Selected.Tasks.Items) {
+			doc.Projects.Selected.Tasks.Selected = doc.Projects.Selected.Tasks.Items[s.task]
+		}
+	}
+	writeArchives(s.archives)
+	doc.undoBase = s
+	flushDirty()
+}
+
+func undo(g *gocui.Gui, v *gocui.View) error {
+	if len(doc.undoStack) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	doc.redoStack = append(doc.redoStack, currentSnapshot())
+	s := doc.undoStack[len(doc.undoStack)-1]
+	doc.undoStack = doc.undoStack[:len(doc.undoStack)-1]
+	restoreSnapshot(s)
+	return nil
+}
+
+func redo(g *gocui.Gui, v *gocui.View) error {
+	if len(doc.redoStack) == 0 {
+		return fmt.Errorf("nothing to redo")
+	}
+	doc.undoStack = append(doc.undoStack, currentSnapshot())
+	s := doc.redoStack[len(doc.redoStack)-1]
+	doc.redoStack = doc.redoStack[:len(doc.redoStack)-1]
+	restoreSnapshot(s)
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package mdtodo
 
 import (
 	"fmt"
@@ -15,7 +15,7 @@ import (
 func SendHeartbeat(filename, project string) {
 	go func() {
 		if project == "" {
-			detectedProject, err := detectProjectName(filename)
+			detectedProject, err := DetectProjectName(filename)
 			if err != nil {
 				log.Printf("Warning: failed to detect project name: %v", err)
 			} else {
@@ -71,10 +71,10 @@ func findWakaTimeCLI() (string, error) {
 	return "", fmt.Errorf("wakatime CLI not found in PATH or at %s", fallbackPath)
 }
 
-// detectProjectName walks up from filename's directory until it finds a .git folder.
+// DetectProjectName walks up from filename's directory until it finds a .git folder.
 // If found, returns the name of that directory (which we treat as the project name).
 // If not found, returns the immediate parent folder’s name as a fallback.
-func detectProjectName(filename string) (string, error) {
+func DetectProjectName(filename string) (string, error) {
 	absPath, err := filepath.Abs(filename)
 	if err != nil {
 		return "", err